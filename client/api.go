@@ -0,0 +1,110 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/TutuanHo03/remote-control/models"
+)
+
+// setCredentials adds the API token of the client to a request
+func setCredentials(req *http.Request, token string) {
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+}
+
+// doJSON sends a request with an optional JSON body to the server and decodes
+// the JSON answer into out; error answers are returned as errors
+func (c *Client) doJSON(ctx context.Context, method, path string, body any, out any) (int, error) {
+	if c.serverURL == "" {
+		return 0, fmt.Errorf("not connected to a server")
+	}
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return 0, fmt.Errorf("failed to marshal request: %v", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.serverURL+path, reader)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %v", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	setCredentials(req, c.token)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to reach server: %v", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, fmt.Errorf("failed to read response: %v", err)
+	}
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		var errBody struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(data, &errBody) == nil && errBody.Error != "" {
+			return resp.StatusCode, fmt.Errorf("server error: %s", errBody.Error)
+		}
+		return resp.StatusCode, fmt.Errorf("server error: %s", resp.Status)
+	}
+
+	if out != nil {
+		if err := json.Unmarshal(data, out); err != nil {
+			return resp.StatusCode, fmt.Errorf("failed to parse response: %v\nresponse body: %s", err, string(data))
+		}
+	}
+
+	return resp.StatusCode, nil
+}
+
+// postCommand posts a command request to the server at serverURL with an
+// optional API token through httpClient; unlike doJSON error answers are decoded too since they
+// carry the structured result
+func postCommand(ctx context.Context, httpClient *http.Client, serverURL, token string, cmdReq models.CommandRequest) (models.CommandResponse, int, error) {
+	var response models.CommandResponse
+
+	jsonData, err := json.Marshal(cmdReq)
+	if err != nil {
+		return response, 0, fmt.Errorf("failed to marshal command request: %v", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, serverURL+"/api/exec", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return response, 0, fmt.Errorf("failed to create request: %v", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	setCredentials(httpReq, token)
+
+	resp, err := httpClient.Do(httpReq)
+	if err != nil {
+		return response, 0, fmt.Errorf("failed to send command: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return response, resp.StatusCode, fmt.Errorf("failed to read response: %v", err)
+	}
+
+	if err := json.Unmarshal(body, &response); err != nil {
+		return response, resp.StatusCode, fmt.Errorf("failed to parse response: %v\nresponse body: %s", err, string(body))
+	}
+
+	return response, resp.StatusCode, nil
+}
@@ -0,0 +1,127 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/TutuanHo03/remote-control/models"
+
+	"github.com/abiosoft/ishell"
+)
+
+const foreachUsage = `Usage: foreach [-j <parallel>] <node-type> <selector> <command> [args...]
+  selector is a glob (imsi-0010*), a comma separated list (imsi-001,imsi-002)
+  or a regular expression between slashes (/^imsi-00[1-5]$/)
+Example: foreach -j 50 ue imsi-0010* register`
+
+// foreachCmd builds the foreach command running a command on many nodes
+func (c *Client) foreachCmd() *ishell.Cmd {
+	return &ishell.Cmd{
+		Name:      "foreach",
+		Help:      "Run a command on many nodes [foreach ue imsi-0010* register]",
+		LongHelp:  foreachUsage,
+		Completer: c.completeForeach,
+		Func: func(ctx *ishell.Context) {
+			req, err := parseForeachArgs(ctx.Args)
+			if err != nil {
+				ctx.Err(fmt.Errorf("%v\n%s", err, foreachUsage))
+				return
+			}
+			ctx.Err(c.runBulk(req))
+		},
+	}
+}
+
+// parseForeachArgs converts foreach arguments into a bulk request
+func parseForeachArgs(args []string) (models.BulkRequest, error) {
+	var req models.BulkRequest
+
+	if len(args) > 0 && (args[0] == "-j" || args[0] == "--parallel") {
+		if len(args) < 2 {
+			return req, fmt.Errorf("%s needs a value", args[0])
+		}
+		concurrency, err := strconv.Atoi(args[1])
+		if err != nil || concurrency <= 0 {
+			return req, fmt.Errorf("invalid parallelism %q", args[1])
+		}
+		req.Concurrency = concurrency
+		args = args[2:]
+	}
+
+	if len(args) < 3 {
+		return req, fmt.Errorf("node type, selector and command are required")
+	}
+
+	req.NodeType = args[0]
+	selector := args[1]
+	req.CommandPath = args[2]
+	req.Args = args[3:]
+
+	switch {
+	case len(selector) > 1 && strings.HasPrefix(selector, "/") && strings.HasSuffix(selector, "/"):
+		req.Regex = selector[1 : len(selector)-1]
+	case strings.Contains(selector, ","):
+		req.Names = strings.Split(selector, ",")
+	case strings.ContainsAny(selector, "*?["):
+		req.Pattern = selector
+	default:
+		req.Names = []string{selector}
+	}
+
+	return req, nil
+}
+
+// runBulk sends a bulk request and prints the per-node result table, failing
+// when any node failed; Ctrl-C cancels the nodes that have not finished yet
+func (c *Client) runBulk(req models.BulkRequest) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	var response models.BulkResponse
+	if _, err := c.doJSON(ctx, http.MethodPost, "/api/bulk", req, &response); err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("bulk command canceled")
+		}
+		return err
+	}
+
+	c.shell.Println(c.renderBulkResponse(response))
+	if response.Failed > 0 {
+		return fmt.Errorf("the command failed on %d of %d nodes", response.Failed, response.Total)
+	}
+	return nil
+}
+
+// renderBulkResponse formats a bulk response according to the output format
+func (c *Client) renderBulkResponse(response models.BulkResponse) string {
+	if c.outputFormat == OutputJSON {
+		data, err := json.MarshalIndent(response, "", "  ")
+		if err == nil {
+			return string(data)
+		}
+	}
+
+	if response.Total == 0 {
+		return "No node matched the selector"
+	}
+
+	var sb strings.Builder
+	w := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NODE\tSTATUS\tCODE\tDURATION\tMESSAGE")
+	for _, result := range response.Results {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%dms\t%s\n", result.NodeName, result.Status,
+			result.ErrorCode, result.DurationMs, strings.ReplaceAll(result.Message, "\n", " "))
+	}
+	w.Flush()
+	sb.WriteString(fmt.Sprintf("%d nodes: %d succeeded, %d failed in %dms",
+		response.Total, response.Succeeded, response.Failed, response.DurationMs))
+
+	return sb.String()
+}
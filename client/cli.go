@@ -0,0 +1,294 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/TutuanHo03/remote-control/models"
+
+	"github.com/urfave/cli/v3"
+)
+
+// Exit codes of the remote-control command
+const (
+	ExitOK           = 0 // The command succeeded
+	ExitFailed       = 1 // The command ran but failed
+	ExitUsage        = 2 // The command line or the request was invalid
+	ExitNotFound     = 3 // The node does not exist
+	ExitTimeout      = 4 // The command timed out or was canceled
+	ExitUnreachable  = 5 // The server could not be reached
+	ExitServerFailed = 6 // The server answered with an unexpected error
+	ExitDenied       = 7 // The credentials were missing, invalid or not allowed to run the command
+)
+
+// DefaultServerURL is the server used when --server is not given
+const DefaultServerURL = "http://localhost:4000"
+
+// NewCommand builds the remote-control command line: the interactive shell by
+// default, plus exec for single commands and run for scripts
+func NewCommand() *cli.Command {
+	serverFlag := &cli.StringFlag{
+		Name:    "server",
+		Aliases: []string{"s"},
+		Usage:   "URL of the remote-control server",
+		Value:   DefaultServerURL,
+		Sources: cli.EnvVars("REMOTE_CONTROL_SERVER"),
+	}
+	tokenFlag := &cli.StringFlag{
+		Name:    "token",
+		Aliases: []string{"t"},
+		Usage:   "API token or JWT of servers requiring authentication",
+		Sources: cli.EnvVars("REMOTE_CONTROL_TOKEN"),
+	}
+	tlsFlags := []cli.Flag{
+		&cli.StringFlag{
+			Name:    "ca-cert",
+			Usage:   "PEM bundle of the CAs trusted for the server certificate",
+			Sources: cli.EnvVars("REMOTE_CONTROL_CA_CERT"),
+		},
+		&cli.StringFlag{
+			Name:    "cert",
+			Usage:   "PEM client certificate for servers requiring mutual TLS",
+			Sources: cli.EnvVars("REMOTE_CONTROL_CERT"),
+		},
+		&cli.StringFlag{
+			Name:    "key",
+			Usage:   "PEM private key of the client certificate",
+			Sources: cli.EnvVars("REMOTE_CONTROL_KEY"),
+		},
+		&cli.BoolFlag{
+			Name:    "insecure",
+			Usage:   "Accept any server certificate, for lab setups only",
+			Sources: cli.EnvVars("REMOTE_CONTROL_INSECURE"),
+		},
+	}
+	outputFlag := &cli.StringFlag{
+		Name:    "output",
+		Aliases: []string{"o"},
+		Usage:   "Result format, text or json",
+		Value:   OutputText,
+	}
+
+	return &cli.Command{
+		Name:           "remote-control",
+		Usage:          "Control the UEs and gNBs of an emulator",
+		DefaultCommand: "shell",
+		Commands: []*cli.Command{
+			{
+				Name:  "shell",
+				Usage: "Start the interactive shell",
+				Flags: append([]cli.Flag{
+					&cli.StringFlag{
+						Name:    "server",
+						Aliases: []string{"s"},
+						Usage:   "Connect to this server on startup",
+						Sources: cli.EnvVars("REMOTE_CONTROL_SERVER"),
+					},
+					tokenFlag,
+				}, tlsFlags...),
+				Action: func(ctx context.Context, cmd *cli.Command) error {
+					c := NewClient()
+					c.SetToken(cmd.String("token"))
+					if err := c.SetTLS(tlsOptions(cmd)); err != nil {
+						return cli.Exit(err, ExitUsage)
+					}
+					if url := cmd.String("server"); url != "" {
+						if err := c.ConnectToServer(url); err != nil {
+							fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+						}
+					}
+					c.Run()
+					return nil
+				},
+			},
+			{
+				Name:      "exec",
+				Usage:     "Run a single command on a node",
+				ArgsUsage: "<node-type> <node-name> <command> [args...]",
+				Description: "Options must precede the node type, everything after it is sent to the node command, e.g.\n" +
+					"remote-control exec --server http://localhost:4000 ue imsi-001 register --emergency",
+				Flags: append([]cli.Flag{serverFlag, tokenFlag, outputFlag}, tlsFlags...),
+				// Node command flags are not ours, execAction parses the leading options itself
+				SkipFlagParsing: true,
+				Action: func(ctx context.Context, cmd *cli.Command) error {
+					return execAction(ctx, cmd)
+				},
+			},
+			{
+				Name:      "run",
+				Usage:     "Run the shell commands of a script file, - reads stdin",
+				ArgsUsage: "<script>",
+				Flags: append([]cli.Flag{
+					tokenFlag,
+					&cli.BoolFlag{
+						Name:  "continue-on-error",
+						Usage: "Keep running after a failed command",
+					},
+					&cli.BoolFlag{
+						Name:  "echo",
+						Usage: "Print each command before running it",
+					},
+					&cli.StringMapFlag{
+						Name:  "var",
+						Usage: "Define a script variable, NAME=value",
+					},
+				}, tlsFlags...),
+				Action: func(ctx context.Context, cmd *cli.Command) error {
+					if cmd.Args().Len() != 1 {
+						return cli.Exit("usage: remote-control run [options] <script>", ExitUsage)
+					}
+
+					c := NewScriptClient(os.Stdout)
+					c.SetToken(cmd.String("token"))
+					if err := c.SetTLS(tlsOptions(cmd)); err != nil {
+						return cli.Exit(err, ExitUsage)
+					}
+					err := c.RunScriptFile(cmd.Args().First(), ScriptOptions{
+						ContinueOnError: cmd.Bool("continue-on-error"),
+						Echo:            cmd.Bool("echo"),
+						Vars:            cmd.StringMap("var"),
+					})
+					if err != nil {
+						// The failed lines were already reported by the script
+						return cli.Exit("", ExitFailed)
+					}
+					return nil
+				},
+			},
+		},
+	}
+}
+
+// execAction sends the command given on the command line and prints its result
+func execAction(ctx context.Context, cmd *cli.Command) error {
+	// Parsing stops at the node type, so node command flags are passed through
+	set := flag.NewFlagSet("exec", flag.ContinueOnError)
+	set.SetOutput(io.Discard)
+	var server, token, output string
+	for _, name := range []string{"server", "s"} {
+		set.StringVar(&server, name, cmd.String("server"), "")
+	}
+	for _, name := range []string{"token", "t"} {
+		set.StringVar(&token, name, cmd.String("token"), "")
+	}
+	for _, name := range []string{"output", "o"} {
+		set.StringVar(&output, name, cmd.String("output"), "")
+	}
+	opts := tlsOptions(cmd)
+	set.StringVar(&opts.CAFile, "ca-cert", opts.CAFile, "")
+	set.StringVar(&opts.CertFile, "cert", opts.CertFile, "")
+	set.StringVar(&opts.KeyFile, "key", opts.KeyFile, "")
+	set.BoolVar(&opts.InsecureSkipVerify, "insecure", opts.InsecureSkipVerify, "")
+	if err := set.Parse(cmd.Args().Slice()); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return cli.ShowSubcommandHelp(cmd)
+		}
+		return cli.Exit(err, ExitUsage)
+	}
+
+	args := set.Args()
+	if len(args) < 3 {
+		return cli.Exit("usage: remote-control exec [options] <node-type> <node-name> <command> [args...]", ExitUsage)
+	}
+
+	if output != OutputText && output != OutputJSON {
+		return cli.Exit(fmt.Sprintf("unknown output format %q, use %s or %s", output, OutputText, OutputJSON), ExitUsage)
+	}
+
+	httpClient, err := newHTTPClient(opts)
+	if err != nil {
+		return cli.Exit(err, ExitUsage)
+	}
+	serverURL := normalizeURL(strings.TrimSuffix(server, "/"), opts.enabled())
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+
+	response, status, err := postCommand(ctx, httpClient, serverURL, token, models.CommandRequest{
+		NodeType:    args[0],
+		NodeName:    args[1],
+		CommandPath: args[2],
+		Args:        args[3:],
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			return cli.Exit("command canceled", ExitTimeout)
+		}
+		if status == 0 {
+			return cli.Exit(err, ExitUnreachable)
+		}
+		return cli.Exit(err, ExitServerFailed)
+	}
+
+	c := &Client{outputFormat: output}
+	rendered := c.renderResponse(response)
+
+	// Procedures that ran but failed answer with a failed result and no error
+	if response.Error == "" && (response.Result == nil || response.Result.Succeeded()) {
+		fmt.Println(rendered)
+		return nil
+	}
+
+	code := exitCode(status, response.Result)
+	if response.Result == nil {
+		return cli.Exit(response.Error, code)
+	}
+	// Failed results are still results, JSON consumers read them from stdout
+	if output == OutputJSON {
+		fmt.Println(rendered)
+		return cli.Exit("", code)
+	}
+	return cli.Exit(rendered, code)
+}
+
+// tlsOptions reads the TLS flags of a command
+func tlsOptions(cmd *cli.Command) TLSOptions {
+	return TLSOptions{
+		CAFile:             cmd.String("ca-cert"),
+		CertFile:           cmd.String("cert"),
+		KeyFile:            cmd.String("key"),
+		InsecureSkipVerify: cmd.Bool("insecure"),
+	}
+}
+
+// exitCode maps a failed command answer to the exit code of exec
+func exitCode(status int, result *models.CommandResult) int {
+	if result != nil {
+		switch result.ErrorCode {
+		case models.ErrCodeNodeNotFound:
+			return ExitNotFound
+		case models.ErrCodeUnauthorized, models.ErrCodeForbidden:
+			return ExitDenied
+		case models.ErrCodeTimeout, models.ErrCodeCanceled:
+			return ExitTimeout
+		case models.ErrCodeInvalidCommand, models.ErrCodeInvalidNodeType,
+			models.ErrCodeInvalidRequest, models.ErrCodeInvalidFlag,
+			models.ErrCodeValidation:
+			return ExitUsage
+		case models.ErrCodeOperationFailed:
+			return ExitFailed
+		}
+	}
+
+	switch {
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return ExitDenied
+	case status == http.StatusNotFound:
+		return ExitNotFound
+	case status == http.StatusGatewayTimeout || status == http.StatusRequestTimeout:
+		return ExitTimeout
+	case status == http.StatusBadRequest:
+		return ExitUsage
+	case status >= http.StatusInternalServerError:
+		return ExitServerFailed
+	default:
+		return ExitFailed
+	}
+}
@@ -2,11 +2,13 @@ package client
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"net/http"
 	"os"
+	"os/signal"
 	"strings"
 
 	"github.com/TutuanHo03/remote-control/models"
@@ -18,13 +20,27 @@ import (
 type Client struct {
 	shell        *ishell.Shell
 	serverURL    string
+	sessionID    string
 	contextStack []models.ClientContext
+	outputFormat string
+	token        string // API token sent to the server, if any
+	httpClient   *http.Client
+	tls          bool // Server URLs without a scheme use https
+	nodeCache    map[string]nodeList
+	nodeCmds     []string // Commands of the current node, removed when leaving it
 }
 
 // NewClient creates and initializes a new CLI client
 func NewClient() *Client {
+	return newClient(ishell.New())
+}
+
+// newClient creates a client on top of the given shell
+func newClient(shell *ishell.Shell) *Client {
 	client := &Client{
-		shell: ishell.New(),
+		shell:        shell,
+		outputFormat: OutputText,
+		httpClient:   http.DefaultClient,
 		contextStack: []models.ClientContext{
 			{
 				Type:     "root",
@@ -45,24 +61,33 @@ func (c *Client) Run() {
 	c.shell.Run()
 }
 
-func (c *Client) ConnectWithHostAndPort(host string, port string) {
+// SetToken sets the API token or JWT sent to servers requiring authentication
+func (c *Client) SetToken(token string) {
+	c.token = token
+}
+
+func (c *Client) ConnectWithHostAndPort(host string, port string) error {
 	if host == "" {
 		host = "localhost"
 	}
-	url := fmt.Sprintf("http://%s:%s", host, port)
-	c.ConnectToServer(url)
+	return c.ConnectToServer(normalizeURL(fmt.Sprintf("%s:%s", host, port), c.tls))
 }
 
-func (c *Client) ConnectWithPort(port string) {
-	c.ConnectWithHostAndPort("localhost", port)
+func (c *Client) ConnectWithPort(port string) error {
+	return c.ConnectWithHostAndPort("localhost", port)
 }
 
 // setupCommands sets up the commands for the shell based on the context
 func (c *Client) setupCommands(contextType string) {
-	// Clear existing commands to avoid duplicates
-	for _, cmd := range []string{"help", "clear", "exit", "back", "disconnect", "use", "select", "connect"} {
+	// Clear existing commands to avoid duplicates, and the commands of the
+	// node left, so they cannot run on it from another context
+	for _, cmd := range []string{"help", "clear", "exit", "output", "jobs", "watch", "foreach", "back", "disconnect", "use", "select", "connect"} {
 		c.shell.DeleteCmd(cmd)
 	}
+	for _, cmd := range c.nodeCmds {
+		c.shell.DeleteCmd(cmd)
+	}
+	c.nodeCmds = nil
 
 	// Add basic commands
 	c.shell.AddCmd(&ishell.Cmd{
@@ -88,20 +113,43 @@ func (c *Client) setupCommands(contextType string) {
 		},
 	})
 
+	c.shell.AddCmd(&ishell.Cmd{
+		Name: "output",
+		Help: "Set the result format [output text | json]",
+		Completer: func(args []string) []string {
+			if len(args) > 0 {
+				return nil
+			}
+			return []string{OutputText, OutputJSON}
+		},
+		Func: func(ctx *ishell.Context) {
+			if len(ctx.Args) < 1 {
+				ctx.Printf("Output format: %s\n", c.outputFormat)
+				return
+			}
+			if err := c.SetOutputFormat(ctx.Args[0]); err != nil {
+				ctx.Err(err)
+			}
+		},
+	})
+
 	// Context-specific commands
 	switch contextType {
 	case "root":
 		c.shell.AddCmd(&ishell.Cmd{
 			Name:     "connect",
-			Help:     "Connect to a MSSim [connect http://localhost:4000]",
-			LongHelp: "Connect to a server using URL. Example: connect http://localhost:4000",
+			Help:     "Connect to a MSSim [connect http://localhost:4000 [--token <token>]]",
+			LongHelp: "Connect to a server using URL, with an API token or JWT when the server requires one.\nExample: connect http://localhost:4000 --token $TOKEN",
 			Func: func(ctx *ishell.Context) {
-				if len(ctx.Args) < 1 {
-					ctx.Println("Usage: connect <server-url>")
+				url, token, hasToken, err := parseConnectArgs(ctx.Args)
+				if err != nil {
+					ctx.Err(err)
 					return
 				}
-				url := ctx.Args[0]
-				c.ConnectToServer(url)
+				if hasToken {
+					c.SetToken(token)
+				}
+				ctx.Err(c.ConnectToServer(url))
 			},
 		})
 
@@ -110,7 +158,7 @@ func (c *Client) setupCommands(contextType string) {
 			Name: "back",
 			Help: "Go back to previous context",
 			Func: func(ctx *ishell.Context) {
-				c.navigateContext("back", nil)
+				ctx.Err(c.navigateContext("back", nil))
 			},
 		})
 
@@ -118,20 +166,20 @@ func (c *Client) setupCommands(contextType string) {
 			Name: "disconnect",
 			Help: "Disconnect from server",
 			Func: func(ctx *ishell.Context) {
-				c.navigateContext("disconnect", nil)
+				ctx.Err(c.navigateContext("disconnect", nil))
 			},
 		})
 
 		c.shell.AddCmd(&ishell.Cmd{
-			Name: "use",
-			Help: "Select a context to use [use emulator | ue | gnb]",
+			Name:      "use",
+			Help:      fmt.Sprintf("Select a context to use [%s]", c.useUsage()),
+			Completer: c.completeUse,
 			Func: func(ctx *ishell.Context) {
 				if len(ctx.Args) < 1 {
-					ctx.Println("Usage: use <context-type>")
-					ctx.Println("Context types: emulator, ue, gnb")
+					ctx.Err(fmt.Errorf("usage: use <context-type>, context types: %s", strings.Join(c.nodeTypes(), ", ")))
 					return
 				}
-				c.navigateContext("use", ctx.Args)
+				ctx.Err(c.navigateContext("use", ctx.Args))
 			},
 		})
 
@@ -140,7 +188,7 @@ func (c *Client) setupCommands(contextType string) {
 			Name: "back",
 			Help: "Go back to previous context",
 			Func: func(ctx *ishell.Context) {
-				c.navigateContext("back", nil)
+				ctx.Err(c.navigateContext("back", nil))
 			},
 		})
 
@@ -148,19 +196,20 @@ func (c *Client) setupCommands(contextType string) {
 			Name: "disconnect",
 			Help: "Disconnect from server",
 			Func: func(ctx *ishell.Context) {
-				c.navigateContext("disconnect", nil)
+				ctx.Err(c.navigateContext("disconnect", nil))
 			},
 		})
 
 		c.shell.AddCmd(&ishell.Cmd{
-			Name: "select",
-			Help: "Select a node to interact with [select <node-name>]",
+			Name:      "select",
+			Help:      "Select a node to interact with [select <node-name>]",
+			Completer: c.completeSelect,
 			Func: func(ctx *ishell.Context) {
 				if len(ctx.Args) < 1 {
-					ctx.Println("Usage: select <node-name>")
+					ctx.Err(errors.New("usage: select <node-name>"))
 					return
 				}
-				c.navigateContext("select", ctx.Args)
+				ctx.Err(c.navigateContext("select", ctx.Args))
 			},
 		})
 
@@ -169,7 +218,7 @@ func (c *Client) setupCommands(contextType string) {
 			Name: "back",
 			Help: "Go back to previous context",
 			Func: func(ctx *ishell.Context) {
-				c.navigateContext("back", nil)
+				ctx.Err(c.navigateContext("back", nil))
 			},
 		})
 
@@ -177,29 +226,41 @@ func (c *Client) setupCommands(contextType string) {
 			Name: "disconnect",
 			Help: "Disconnect from server",
 			Func: func(ctx *ishell.Context) {
-				c.navigateContext("disconnect", nil)
+				ctx.Err(c.navigateContext("disconnect", nil))
 			},
 		})
 	}
-}
 
-// connectToServer handles server connection
-func (c *Client) ConnectToServer(url string) {
-	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
-		url = "http://" + url
+	if contextType != "root" {
+		c.shell.AddCmd(c.jobsCmd())
+		c.shell.AddCmd(c.watchCmd())
+		c.shell.AddCmd(c.foreachCmd())
 	}
+}
 
+// ConnectToServer handles server connection
+func (c *Client) ConnectToServer(url string) error {
+	url = normalizeURL(url, c.tls)
 	c.serverURL = url
 
-	resp, err := http.Get(url + "/api/context")
+	resp, err := c.get(url + "/api/context")
 	if err != nil {
-		c.shell.Printf("Failed to connect to server: %v\n", err)
 		c.serverURL = "" // Reset if failing
-		return
+		return fmt.Errorf("failed to connect to server: %v", err)
 	}
 	defer resp.Body.Close()
 
-	c.navigateContext("connect", []string{url})
+	return c.navigateContext("connect", []string{url})
+}
+
+// useUsage returns the usage of the use command with the context types of
+// the connected server, including the registered node types
+func (c *Client) useUsage() string {
+	types := c.nodeTypes()
+	if len(types) == 0 {
+		return "use <context-type>"
+	}
+	return "use " + strings.Join(types, " | ")
 }
 
 // displayHelp generates help text for the current context
@@ -214,6 +275,7 @@ func (c *Client) displayHelp() func(*ishell.Context) {
 			ctx.Println("  connect      Connect to a MSSim [connect http://localhost:4000]")
 			ctx.Println("  exit         exit the program")
 			ctx.Println("  help         display help")
+			ctx.Println("  output       set the result format [output text | json]")
 
 		case "server":
 			ctx.Println("Commands:")
@@ -222,7 +284,11 @@ func (c *Client) displayHelp() func(*ishell.Context) {
 			ctx.Println("  disconnect          Disconnect server")
 			ctx.Println("  exit                Exit the client")
 			ctx.Println("  help                Display help")
-			ctx.Println("  use                 Select a context to use [use emulator | ue | gnb]")
+			ctx.Println("  jobs                List and manage asynchronous jobs")
+			ctx.Println("  watch               Print node events until Ctrl-C")
+			ctx.Println("  foreach             Run a command on many nodes [foreach ue imsi-0010* register]")
+			ctx.Println("  output              Set the result format [output text | json]")
+			ctx.Printf("  use                 Select a context to use [%s]\n", c.useUsage())
 
 		case "context_set":
 			ctx.Println("Available commands :")
@@ -234,6 +300,10 @@ func (c *Client) displayHelp() func(*ishell.Context) {
 			ctx.Println("  disconnect          Disconnect server")
 			ctx.Println("  exit                Exit the client")
 			ctx.Println("  help                Display this help")
+			ctx.Println("  jobs                List and manage asynchronous jobs")
+			ctx.Println("  watch               Print node events until Ctrl-C")
+			ctx.Println("  foreach             Run a command on many nodes [foreach ue imsi-0010* register]")
+			ctx.Println("  output              Set the result format [output text | json]")
 
 		case "node":
 			ctx.Printf("Available commands for %s :\n", currentContext.Name)
@@ -245,7 +315,7 @@ func (c *Client) displayHelp() func(*ishell.Context) {
 			} else {
 				// Fallback if no command info available
 				for _, cmd := range currentContext.Commands {
-					if cmd != "help" && cmd != "clear" && cmd != "exit" && cmd != "back" && cmd != "disconnect" {
+					if cmd != "help" && cmd != "clear" && cmd != "exit" && cmd != "output" && cmd != "jobs" && cmd != "watch" && cmd != "foreach" && cmd != "back" && cmd != "disconnect" {
 						ctx.Printf("  %-16s\n", cmd)
 					}
 				}
@@ -258,15 +328,20 @@ func (c *Client) displayHelp() func(*ishell.Context) {
 			ctx.Println("  disconnect          Disconnect server")
 			ctx.Println("  exit                Exit the client")
 			ctx.Println("  help                Display this help")
+			ctx.Println("  jobs                List and manage asynchronous jobs")
+			ctx.Println("  watch               Print node events until Ctrl-C")
+			ctx.Println("  foreach             Run a command on many nodes [foreach ue imsi-0010* register]")
+			ctx.Println("  output              Set the result format [output text | json]")
 		}
 	}
 }
 
 // navigateContext handles navigation between contexts
-func (c *Client) navigateContext(command string, args []string) {
+func (c *Client) navigateContext(command string, args []string) error {
 	currentContext := c.getCurrentContext()
 
 	req := models.NavigationRequest{
+		SessionID:      c.sessionID,
 		CurrentContext: currentContext.Name,
 		Command:        command,
 		Args:           args,
@@ -276,14 +351,10 @@ func (c *Client) navigateContext(command string, args []string) {
 
 	if command == "connect" && (c.serverURL == "" || len(c.contextStack) <= 1) {
 		if len(args) < 1 {
-			c.shell.Println("URL is required for connect command")
-			return
+			return fmt.Errorf("URL is required for connect command")
 		}
 
-		c.serverURL = args[0]
-		if !strings.HasPrefix(c.serverURL, "http://") && !strings.HasPrefix(c.serverURL, "https://") {
-			c.serverURL = "http://" + c.serverURL
-		}
+		c.serverURL = normalizeURL(args[0], c.tls)
 	}
 
 	endpoint := c.serverURL + "/api/context/navigate"
@@ -291,64 +362,61 @@ func (c *Client) navigateContext(command string, args []string) {
 	// send request
 	jsonData, err := json.Marshal(req)
 	if err != nil {
-		c.shell.Printf("Error preparing request: %v\n", err)
-		return
+		return fmt.Errorf("failed to prepare request: %v", err)
 	}
 
-	resp, err := http.Post(endpoint, "application/json", bytes.NewBuffer(jsonData))
+	httpReq, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewBuffer(jsonData))
 	if err != nil {
-		c.shell.Printf("Error communicating with server: %v\n", err)
-		return
+		return fmt.Errorf("failed to prepare request: %v", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	setCredentials(httpReq, c.token)
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("failed to communicate with server: %v", err)
 	}
 	defer resp.Body.Close()
 
 	// Process response
 	var response models.NavigationResponse
 	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
-		c.shell.Printf("Error parsing response: %v\n", err)
-		return
+		return fmt.Errorf("failed to parse response: %v", err)
 	}
 
-	if response.Error != "" {
-		c.shell.Printf("Error: %s\n", response.Error)
-		return
-	}
-
-	// Update context stack
-	if command == "back" || command == "disconnect" {
-		if len(c.contextStack) > 1 {
-			c.contextStack = c.contextStack[:len(c.contextStack)-1]
+	c.printNotices(response.Notices)
 
-			// Special handling for disconnect
-			if command == "disconnect" {
-				// Reset to root context
-				c.contextStack = c.contextStack[:1]
-				c.serverURL = ""
+	if resp.StatusCode == http.StatusGone {
+		c.resetToRoot()
+		return errors.New(response.Error)
+	}
 
-				c.setupCommands("root")
-				c.shell.SetPrompt(">>> ")
-				c.shell.Println("Disconnected from server")
-				return
-			}
+	if response.Error != "" {
+		return errors.New(response.Error)
+	}
 
-			currentContext = c.getCurrentContext()
-			c.setupCommands(currentContext.Type)
-		} else {
-			c.shell.Println("Already at root context")
-			return
-		}
-	} else {
-		response.Context.ServerURL = c.serverURL
+	if command == "connect" {
+		c.sessionID = response.SessionID
+	}
 
-		if command == "use" && len(args) > 0 {
-			response.Context.NodeType = args[0]
-		}
+	if command == "disconnect" {
+		c.contextStack = c.contextStack[:1]
+		c.serverURL = ""
 
-		// Add new context to stack
-		c.contextStack = append(c.contextStack, response.Context)
+		c.setupCommands("root")
+		c.shell.SetPrompt(">>> ")
+		c.shell.Println("Disconnected from server")
+		return nil
+	}
 
-		c.setupCommands(response.Context.Type)
+	// Follow the context the server reports, which also covers the session
+	// being moved out of a node the server pruned since the last request
+	response.Context.ServerURL = c.serverURL
+	if command == "use" && len(args) > 0 {
+		response.Context.NodeType = args[0]
 	}
+	c.enterContext(response.Context)
+	c.setupCommands(response.Context.Type)
 
 	// Update prompt
 	if response.Prompt != "" {
@@ -366,10 +434,96 @@ func (c *Client) navigateContext(command string, args []string) {
 		c.shell.Println(response.Message)
 	}
 
-	// Setup node commands if applicable
-	if command == "select" || (command == "use" && args[0] == "emulator") {
+	// Setup node commands if applicable; use enters single node types directly
+	if response.Context.Type == "node" && (command == "select" || command == "use") {
 		c.setupNodeCommands(response.Context, response.Commands)
 	}
+
+	return nil
+}
+
+// parseConnectArgs splits the arguments of connect into the server URL and
+// the optional --token value
+func parseConnectArgs(args []string) (url, token string, hasToken bool, err error) {
+	usage := errors.New("usage: connect <server-url> [--token <token>]")
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i]; {
+		case arg == "--token":
+			if i+1 >= len(args) {
+				return "", "", false, usage
+			}
+			i++
+			token, hasToken = args[i], true
+		case strings.HasPrefix(arg, "--token="):
+			token, hasToken = strings.TrimPrefix(arg, "--token="), true
+		case url == "" && !strings.HasPrefix(arg, "-"):
+			url = arg
+		default:
+			return "", "", false, usage
+		}
+	}
+	if url == "" {
+		return "", "", false, usage
+	}
+	return url, token, hasToken, nil
+}
+
+// get sends a GET request carrying the credentials of the client
+func (c *Client) get(url string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	setCredentials(req, c.token)
+	return c.httpClient.Do(req)
+}
+
+// resetToRoot drops the server session and returns to the root context
+func (c *Client) resetToRoot() {
+	c.contextStack = c.contextStack[:1]
+	c.serverURL = ""
+	c.sessionID = ""
+
+	c.setupCommands("root")
+	c.shell.SetPrompt(">>> ")
+}
+
+// enterContext makes ctx the current context of the stack. The contexts above
+// it, or above its parent when it is new, are dropped, so the stack matches
+// the session of the server even when the server left a context on its own.
+func (c *Client) enterContext(ctx models.ClientContext) {
+	for i := len(c.contextStack) - 1; i >= 0; i-- {
+		stacked := c.contextStack[i]
+		if stacked.Type == ctx.Type && stacked.Name == ctx.Name {
+			c.contextStack = append(c.contextStack[:i], ctx)
+			return
+		}
+		if stacked.Type != "node" && stacked.Name == ctx.ParentPath {
+			c.contextStack = append(c.contextStack[:i+1], ctx)
+			return
+		}
+	}
+	c.contextStack = append(c.contextStack, ctx)
+}
+
+// leaveNodeContext pops node contexts off the stack after the node vanished
+func (c *Client) leaveNodeContext() {
+	if c.getCurrentContext().Type != "node" {
+		return
+	}
+	for len(c.contextStack) > 1 && c.getCurrentContext().Type == "node" {
+		c.contextStack = c.contextStack[:len(c.contextStack)-1]
+	}
+
+	c.setupCommands(c.getCurrentContext().Type)
+	c.shell.SetPrompt(c.prompt())
+}
+
+// printNotices shows messages the server queued for this session
+func (c *Client) printNotices(notices []string) {
+	for _, notice := range notices {
+		c.shell.Printf("Notice: %s\n", notice)
+	}
 }
 
 // setupNodeCommands sets up commands for a specific node
@@ -378,23 +532,81 @@ func (c *Client) setupNodeCommands(context models.ClientContext, commands []mode
 		commands = c.requestCommands(context.NodeType, context.Name)
 	}
 
-	for _, cmdInfo := range commands {
-		info := cmdInfo
+	for _, info := range commands {
+		c.shell.AddCmd(c.newNodeCmd(context, info.Name, info))
+		c.nodeCmds = append(c.nodeCmds, info.Name)
+	}
+}
 
-		c.shell.AddCmd(&ishell.Cmd{
-			Name:     info.Name,
-			Help:     info.Usage,
-			LongHelp: c.generateLongHelp(info),
-			Func: func(ctx *ishell.Context) {
-				result, err := c.execCmd(context.NodeType, context.Name, info.Name, ctx.Args)
-				if err != nil {
-					ctx.Printf("Error: %v\n", err)
-					return
-				}
-				ctx.Println(result)
-			},
-		})
+// newNodeCmd builds the shell command of a node command; groups get their
+// subcommands as children, run without one they print their help and fail
+// like the server does, so a script line naming only a group is an error
+func (c *Client) newNodeCmd(context models.ClientContext, path string, info models.CommandInfo) *ishell.Cmd {
+	cmd := &ishell.Cmd{
+		Name:     info.Name,
+		Help:     info.Usage,
+		LongHelp: c.generateLongHelp(path, info),
+	}
+
+	if len(info.Subcommands) > 0 {
+		names := make([]string, 0, len(info.Subcommands))
+		for _, sub := range info.Subcommands {
+			cmd.AddCmd(c.newNodeCmd(context, path+" "+sub.Name, sub))
+			names = append(names, sub.Name)
+		}
+		cmd.Func = func(ctx *ishell.Context) {
+			ctx.Println(cmd.HelpText())
+			switch {
+			case len(ctx.Args) == 0:
+				ctx.Err(fmt.Errorf("incomplete command %q, valid subcommands: %s", path, strings.Join(names, ", ")))
+			case ctx.Args[0] != "--help" && ctx.Args[0] != "-h":
+				ctx.Err(fmt.Errorf("unknown command %q, valid subcommands: %s", path+" "+ctx.Args[0], strings.Join(names, ", ")))
+			}
+		}
+		return cmd
+	}
+
+	cmd.Completer = completeNodeCommand(info)
+	cmd.Func = func(ctx *ishell.Context) {
+		args, async := takeAsyncFlag(ctx.Args)
+		cmdReq, err := c.newCommandRequest(context, path, info, args)
+		if err != nil {
+			ctx.Err(err)
+			return
+		}
+
+		if async {
+			job, err := c.submitJob(cmdReq)
+			if err != nil {
+				ctx.Err(err)
+				return
+			}
+			ctx.Printf("Job %s queued, follow it with: jobs watch %s\n", job.ID, job.ID)
+			return
+		}
+
+		result, err := c.sendCmd(cmdReq)
+		if err != nil {
+			ctx.Err(err)
+			return
+		}
+		ctx.Println(result)
 	}
+	return cmd
+}
+
+// takeAsyncFlag removes --async from the arguments and reports whether it was given
+func takeAsyncFlag(args []string) ([]string, bool) {
+	rest := make([]string, 0, len(args))
+	async := false
+	for _, arg := range args {
+		if arg == "--async" {
+			async = true
+			continue
+		}
+		rest = append(rest, arg)
+	}
+	return rest, async
 }
 
 // requestCommands fetches command definitions from the server
@@ -405,7 +617,7 @@ func (c *Client) requestCommands(nodeType, nodeName string) []models.CommandInfo
 
 	url := fmt.Sprintf("%s/api/context/node/%s/%s/commands", c.serverURL, nodeType, nodeName)
 
-	resp, err := http.Get(url)
+	resp, err := c.get(url)
 	if err != nil || resp.StatusCode != http.StatusOK {
 		return nil
 	}
@@ -419,85 +631,102 @@ func (c *Client) requestCommands(nodeType, nodeName string) []models.CommandInfo
 	return commands
 }
 
-// execCmd executes a command on the server
-func (c *Client) execCmd(nodeType, nodeName, cmdName string, args []string) (string, error) {
-	// Separate args and flags
-	cmdArgs := []string{}
-	cmdFlags := []string{}
+// newCommandRequest builds the request of a node command from the typed
+// tokens, parsed against the flags the server described
+func (c *Client) newCommandRequest(context models.ClientContext, path string, info models.CommandInfo, tokens []string) (models.CommandRequest, error) {
+	cmdReq := models.CommandRequest{
+		SessionID:   c.sessionID,
+		NodeType:    context.NodeType,
+		NodeName:    context.Name,
+		CommandPath: path,
+	}
 
-	for _, arg := range args {
-		if strings.HasPrefix(arg, "--") || strings.HasPrefix(arg, "-") {
-			cmdFlags = append(cmdFlags, arg)
-		} else {
-			cmdArgs = append(cmdArgs, arg)
-		}
+	parsed, err := parseCommandArgs(info, tokens)
+	if err != nil {
+		return cmdReq, err
 	}
 
-	// Check for help flag
-	for _, flag := range cmdFlags {
-		if flag == "--help" || flag == "-h" {
-			cmdReq := models.CommandRequest{
-				NodeType:    nodeType,
-				NodeName:    nodeName,
-				CommandPath: cmdName,
-				Args:        []string{"--help"},
-			}
+	if parsed.Help {
+		cmdReq.Args = []string{"--help"}
+		return cmdReq, nil
+	}
 
-			return c.sendCmd(cmdReq)
-		}
+	cmdReq.Args = parsed.Args
+	if len(parsed.Flags) > 0 {
+		cmdReq.Flags = parsed.Flags
 	}
+	return cmdReq, nil
+}
 
-	// Execute normal command with all args and flags
-	allArgs := append(cmdArgs, cmdFlags...)
-	cmdReq := models.CommandRequest{
-		NodeType:    nodeType,
-		NodeName:    nodeName,
-		CommandPath: cmdName,
-		Args:        allArgs,
+// sendCmd sends a command request to the server and renders the result
+func (c *Client) sendCmd(cmdReq models.CommandRequest) (string, error) {
+	response, err := c.requestExec(cmdReq)
+	if err != nil {
+		return "", err
 	}
 
-	return c.sendCmd(cmdReq)
+	return c.renderResponse(response), nil
 }
 
-// sendCmd sends a command request to the server
-func (c *Client) sendCmd(cmdReq models.CommandRequest) (string, error) {
+// requestExec posts a command request to the server
+func (c *Client) requestExec(cmdReq models.CommandRequest) (models.CommandResponse, error) {
 	if c.serverURL == "" {
-		return "", fmt.Errorf("not connected to a server")
+		return models.CommandResponse{}, fmt.Errorf("not connected to a server")
 	}
 
-	jsonData, err := json.Marshal(cmdReq)
-	if err != nil {
-		return "", fmt.Errorf("failed to marshal command request: %v", err)
-	}
+	// Ctrl-C cancels the in-flight command instead of terminating the client
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
 
-	resp, err := http.Post(c.serverURL+"/api/exec", "application/json", bytes.NewBuffer(jsonData))
+	response, status, err := postCommand(ctx, c.httpClient, c.serverURL, c.token, cmdReq)
 	if err != nil {
-		return "", fmt.Errorf("failed to send command: %v", err)
+		if ctx.Err() != nil {
+			return response, fmt.Errorf("command canceled")
+		}
+		return response, err
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("failed to read response: %v", err)
+	c.printNotices(response.Notices)
+
+	if status == http.StatusGone {
+		c.resetToRoot()
 	}
 
-	var response models.CommandResponse
-	if err := json.Unmarshal(body, &response); err != nil {
-		return "", fmt.Errorf("failed to parse response: %v\nresponse body: %s", err, string(body))
+	// The node was removed while selected, the server already moved the session back
+	if response.Result != nil && response.Result.ErrorCode == models.ErrCodeNodeNotFound {
+		c.leaveNodeContext()
 	}
 
 	if response.Error != "" {
-		return "", fmt.Errorf("server error: %s", response.Error)
+		if response.Result != nil && (c.outputFormat == OutputJSON || len(response.Result.Errors) > 0) {
+			return response, fmt.Errorf("server error:\n%s", c.renderResponse(response))
+		}
+		return response, fmt.Errorf("server error: %s", response.Error)
+	}
+
+	// Procedures that ran but failed answer with a failed result and no error
+	if response.Result != nil && !response.Result.Succeeded() {
+		return response, errors.New(c.renderResponse(response))
 	}
 
-	return response.Response, nil
+	return response, nil
 }
 
-// generateLongHelp creates detailed help for a command
-func (c *Client) generateLongHelp(cmd models.CommandInfo) string {
+// generateLongHelp creates detailed help for a command, ishell appends the
+// subcommands of groups
+func (c *Client) generateLongHelp(path string, cmd models.CommandInfo) string {
 	var sb strings.Builder
 	sb.WriteString("\n")
-	sb.WriteString(cmd.Name)
+	sb.WriteString(path)
+
+	if len(cmd.Subcommands) > 0 {
+		sb.WriteString(" <command> [command options]\n")
+		if cmd.Description != "" {
+			sb.WriteString(cmd.Description)
+			sb.WriteString("\n")
+		}
+		return sb.String()
+	}
 
 	if len(cmd.ArgsUsage) > 0 {
 		sb.WriteString(" ")
@@ -521,6 +750,7 @@ func (c *Client) generateLongHelp(cmd models.CommandInfo) string {
 			sb.WriteString("\n")
 		}
 	}
+	sb.WriteString("   --async:  Run as a background job, see jobs\n")
 
 	return sb.String()
 }
@@ -0,0 +1,97 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/TutuanHo03/remote-control/models"
+)
+
+func TestNavigateFollowsServer(t *testing.T) {
+	root := models.ClientContext{Type: "root", Name: "root"}
+	server := models.ClientContext{Type: "server", Name: "server", ParentPath: "root"}
+	ueSet := models.ClientContext{Type: "context_set", Name: "ue", ParentPath: "server", NodeType: "ue"}
+	ue := func(name string) models.ClientContext {
+		return models.ClientContext{Type: "node", Name: name, ParentPath: "ue", NodeType: "ue"}
+	}
+	emulator := models.ClientContext{Type: "node", Name: "emulator", ParentPath: "emulator", NodeType: "emulator"}
+
+	// The server answers every navigation with the next context
+	var next models.NavigationResponse
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(next)
+	}))
+	defer ts.Close()
+
+	c := NewScriptClient(&bytes.Buffer{})
+	c.serverURL = ts.URL
+	c.sessionID = "session"
+	c.contextStack = []models.ClientContext{root, server, ueSet, ue("imsi-001010000000001")}
+
+	tests := []struct {
+		name    string
+		command string
+		args    []string
+		reply   models.NavigationResponse
+		want    []string // Names of the contexts of the stack
+	}{
+		{
+			name:    "back after the server pruned the node",
+			command: "back",
+			reply:   models.NavigationResponse{Context: server, Notices: []string{"Node imsi-001010000000001 (ue) is gone, back to ue context"}},
+			want:    []string{"root", "server"},
+		},
+		{
+			name:    "use",
+			command: "use",
+			args:    []string{"ue"},
+			reply:   models.NavigationResponse{Context: ueSet},
+			want:    []string{"root", "server", "ue"},
+		},
+		{
+			name:    "select",
+			command: "select",
+			args:    []string{"imsi-001010000000002"},
+			reply:   models.NavigationResponse{Context: ue("imsi-001010000000002")},
+			want:    []string{"root", "server", "ue", "imsi-001010000000002"},
+		},
+		{
+			name:    "back",
+			command: "back",
+			reply:   models.NavigationResponse{Context: ueSet},
+			want:    []string{"root", "server", "ue"},
+		},
+		{
+			name:    "back to the server",
+			command: "back",
+			reply:   models.NavigationResponse{Context: server},
+			want:    []string{"root", "server"},
+		},
+		{
+			name:    "use a singleton type",
+			command: "use",
+			args:    []string{"emulator"},
+			reply:   models.NavigationResponse{Context: emulator},
+			want:    []string{"root", "server", "emulator"},
+		},
+	}
+
+	for _, tc := range tests {
+		next = tc.reply
+		if err := c.navigateContext(tc.command, tc.args); err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+
+		var names []string
+		for _, ctx := range c.contextStack {
+			names = append(names, ctx.Name)
+		}
+		if !slices.Equal(names, tc.want) {
+			t.Errorf("%s: got stack %v, want %v", tc.name, names, tc.want)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/TutuanHo03/remote-control/models"
+)
+
+// Completion fetches node lists from the server; they are cached briefly so
+// that repeated tab presses do not send a request each, and the request is
+// abandoned when the server is slow to answer
+const (
+	completionCacheTTL = 2 * time.Second
+	completionTimeout  = time.Second
+)
+
+// nodeList is a cached list of node names of a type
+type nodeList struct {
+	names     []string
+	fetchedAt time.Time
+}
+
+// nodeNames returns the names of the nodes of a type known to the server
+func (c *Client) nodeNames(nodeType string) []string {
+	if cached, ok := c.nodeCache[nodeType]; ok && time.Since(cached.fetchedAt) < completionCacheTTL {
+		return cached.names
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), completionTimeout)
+	defer cancel()
+
+	var nodes struct {
+		Objects []string `json:"objects"`
+	}
+	if _, err := c.doJSON(ctx, http.MethodGet, "/api/context/node/"+nodeType, nil, &nodes); err != nil {
+		// Fall back to the nodes the context set had when it was entered
+		if current := c.getCurrentContext(); current.Type == "context_set" && current.NodeType == nodeType {
+			return current.ChildrenPaths
+		}
+		return nil
+	}
+
+	if c.nodeCache == nil {
+		c.nodeCache = make(map[string]nodeList)
+	}
+	c.nodeCache[nodeType] = nodeList{names: nodes.Objects, fetchedAt: time.Now()}
+	return nodes.Objects
+}
+
+// completeUse suggests the context types below the server context
+func (c *Client) completeUse(args []string) []string {
+	if len(args) > 0 {
+		return nil
+	}
+	return c.getCurrentContext().ChildrenPaths
+}
+
+// completeSelect suggests the nodes of the current context set
+func (c *Client) completeSelect(args []string) []string {
+	if len(args) > 0 {
+		return nil
+	}
+	return c.nodeNames(c.getCurrentContext().NodeType)
+}
+
+// completeForeach suggests the node type, the nodes and then the commands of foreach
+func (c *Client) completeForeach(args []string) []string {
+	parallel := len(args) > 0 && (args[0] == "-j" || args[0] == "--parallel")
+	if parallel {
+		if len(args) == 1 {
+			return nil
+		}
+		args = args[2:]
+	}
+
+	switch len(args) {
+	case 0:
+		types := c.nodeTypes()
+		if !parallel {
+			types = append(types, "-j")
+		}
+		return types
+	case 1:
+		return c.nodeNames(args[0])
+	case 2:
+		return commandInfoNames(c.requestCommands(args[0], firstNode(args[1])))
+	default:
+		info, rest, found := findCommandInfo(c.requestCommands(args[0], firstNode(args[1])), args[2:])
+		switch {
+		case !found:
+			return nil
+		case len(info.Subcommands) > 0:
+			if len(rest) > 0 {
+				return nil
+			}
+			return commandInfoNames(info.Subcommands)
+		default:
+			return completeNodeCommand(info)(rest)
+		}
+	}
+}
+
+// findCommandInfo walks nested commands along the leading words of a command
+// line and returns the deepest command named with the words left over
+func findCommandInfo(commands []models.CommandInfo, words []string) (models.CommandInfo, []string, bool) {
+	var info models.CommandInfo
+	found := false
+	for len(words) > 0 {
+		i := slices.IndexFunc(commands, func(cmd models.CommandInfo) bool { return cmd.Name == words[0] })
+		if i < 0 {
+			break
+		}
+		info, commands, words, found = commands[i], commands[i].Subcommands, words[1:], true
+	}
+	return info, words, found
+}
+
+// commandInfoNames lists the names of commands
+func commandInfoNames(commands []models.CommandInfo) []string {
+	names := make([]string, 0, len(commands))
+	for _, cmd := range commands {
+		names = append(names, cmd.Name)
+	}
+	return names
+}
+
+// nodeTypes returns the context types of the connected server
+func (c *Client) nodeTypes() []string {
+	for _, ctx := range c.contextStack {
+		if ctx.Type == "server" {
+			return append([]string(nil), ctx.ChildrenPaths...)
+		}
+	}
+	return nil
+}
+
+// firstNode returns a node name to fetch the commands of a foreach selector
+func firstNode(selector string) string {
+	if name, _, found := strings.Cut(selector, ","); found {
+		return name
+	}
+	return selector
+}
+
+// completeNodeCommand suggests the flags of a node command, and the allowed
+// or default values of a flag waiting for its value
+func completeNodeCommand(info models.CommandInfo) func(args []string) []string {
+	return func(args []string) []string {
+		if len(args) > 0 {
+			last := args[len(args)-1]
+			if flag, ok := findFlag(info.Flags, last); ok && !isBoolFlag(flag) && !strings.Contains(last, "=") {
+				switch {
+				case len(flag.Enum) > 0:
+					return flag.Enum
+				case flag.DefaultText != "":
+					return []string{flag.DefaultText}
+				default:
+					return nil
+				}
+			}
+		}
+
+		used := make(map[string]bool, len(args))
+		for _, arg := range args {
+			name, _, _ := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+			used[name] = true
+		}
+
+		suggestions := []string{"--async", "--help"}
+		for _, flag := range info.Flags {
+			names := flagNames(flag)
+			if slices.ContainsFunc(names, func(n string) bool { return used[n] }) {
+				continue
+			}
+			suggestions = append(suggestions, "--"+names[0])
+		}
+		return suggestions
+	}
+}
+
+// findFlag looks up the flag named by a --flag token
+func findFlag(flags []models.FlagInfo, token string) (models.FlagInfo, bool) {
+	if !strings.HasPrefix(token, "-") {
+		return models.FlagInfo{}, false
+	}
+	name, _, _ := strings.Cut(strings.TrimLeft(token, "-"), "=")
+	for _, flag := range flags {
+		for _, n := range flagNames(flag) {
+			if n == name {
+				return flag, true
+			}
+		}
+	}
+	return models.FlagInfo{}, false
+}
+
+// flagNames splits the comma separated names of a flag, the first one is the main name
+func flagNames(flag models.FlagInfo) []string {
+	names := strings.Split(flag.Name, ",")
+	for i := range names {
+		names[i] = strings.TrimSpace(names[i])
+	}
+	return names
+}
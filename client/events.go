@@ -0,0 +1,129 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/TutuanHo03/remote-control/models"
+
+	"github.com/abiosoft/ishell"
+)
+
+// watchCmd builds the watch command streaming node events
+func (c *Client) watchCmd() *ishell.Cmd {
+	return &ishell.Cmd{
+		Name: "watch",
+		Help: "Print node events of the current node or context set until Ctrl-C",
+		Func: func(ctx *ishell.Context) {
+			current := c.getCurrentContext()
+
+			query := url.Values{}
+			switch current.Type {
+			case "node":
+				query.Set("nodeType", current.NodeType)
+				query.Set("nodeName", current.Name)
+			case "context_set":
+				query.Set("nodeType", current.NodeType)
+			}
+
+			ctx.Err(c.watchEvents(query))
+		},
+	}
+}
+
+// watchEvents prints the server-sent events matching query until Ctrl-C
+func (c *Client) watchEvents(query url.Values) error {
+	if c.serverURL == "" {
+		return fmt.Errorf("not connected to a server")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.serverURL+"/api/events?"+query.Encode(), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	setCredentials(req, c.token)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		return fmt.Errorf("failed to reach server: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("server error: %s", resp.Status)
+	}
+
+	c.shell.Println("Watching events, press Ctrl-C to stop")
+
+	// Events are "event:" and "data:" lines terminated by an empty line
+	var eventName, data string
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "event:"):
+			eventName = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data = strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		case line == "":
+			if eventName != "" && eventName != "heartbeat" {
+				c.printEvent(data)
+			}
+			eventName, data = "", ""
+		}
+	}
+
+	if ctx.Err() != nil {
+		c.shell.Println("Stopped watching")
+		return nil
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("event stream interrupted: %v", err)
+	}
+	c.shell.Println("Event stream closed by server")
+	return nil
+}
+
+// printEvent prints one event in the current output format
+func (c *Client) printEvent(data string) {
+	if c.outputFormat == OutputJSON {
+		c.shell.Println(data)
+		return
+	}
+
+	var event models.NodeEvent
+	if err := json.Unmarshal([]byte(data), &event); err != nil {
+		c.shell.Println(data)
+		return
+	}
+
+	keys := make([]string, 0, len(event.Details))
+	for key := range event.Details {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("[%s] %s %s %s", event.Time.Local().Format(time.TimeOnly),
+		event.NodeType, event.NodeName, event.Type))
+	for _, key := range keys {
+		sb.WriteString(fmt.Sprintf(" %s=%s", key, event.Details[key]))
+	}
+	c.shell.Println(sb.String())
+}
@@ -0,0 +1,107 @@
+package client
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/TutuanHo03/remote-control/models"
+)
+
+// parsedArgs is a node command line split against the command's flag metadata
+type parsedArgs struct {
+	Args  []string          // Positional arguments in their original order
+	Flags map[string]string // Flag values by main flag name
+	Help  bool              // --help or -h was given
+}
+
+// parseCommandArgs parses the tokens typed after a node command. Flags are
+// given as --flag=value or --flag value, boolean flags need no value, and
+// everything after -- is positional. Quotes were already handled by the shell.
+func parseCommandArgs(info models.CommandInfo, tokens []string) (parsedArgs, error) {
+	parsed := parsedArgs{Flags: make(map[string]string)}
+
+	for i := 0; i < len(tokens); i++ {
+		token := tokens[i]
+
+		if token == "--" {
+			parsed.Args = append(parsed.Args, tokens[i+1:]...)
+			break
+		}
+		if token == "--help" || token == "-h" {
+			parsed.Help = true
+			continue
+		}
+		if !isFlagToken(token) {
+			parsed.Args = append(parsed.Args, token)
+			continue
+		}
+
+		flag, ok := findFlag(info.Flags, token)
+		if !ok {
+			return parsed, fmt.Errorf("unknown flag %s for %s%s", strings.SplitN(token, "=", 2)[0], info.Name, validFlags(info))
+		}
+		name := flagNames(flag)[0]
+
+		value, hasValue := "", false
+		if _, v, found := strings.Cut(token, "="); found {
+			value, hasValue = v, true
+		}
+
+		switch {
+		case isBoolFlag(flag):
+			if !hasValue {
+				value = "true"
+			} else if _, err := strconv.ParseBool(value); err != nil {
+				return parsed, fmt.Errorf("invalid value %q for flag --%s, expected true or false", value, name)
+			}
+		case !hasValue:
+			if i+1 >= len(tokens) {
+				return parsed, fmt.Errorf("flag --%s needs a value", name)
+			}
+			i++
+			value = tokens[i]
+		}
+
+		if flag.Type == models.FlagTypeInt {
+			if _, err := strconv.ParseInt(value, 0, 64); err != nil {
+				return parsed, fmt.Errorf("invalid value %q for flag --%s, expected an integer", value, name)
+			}
+		}
+
+		parsed.Flags[name] = value
+	}
+
+	return parsed, nil
+}
+
+// isBoolFlag reports whether a flag takes no value; servers without flag
+// types only tell it by the default value
+func isBoolFlag(flag models.FlagInfo) bool {
+	if flag.Type != "" {
+		return flag.Type == models.FlagTypeBool
+	}
+	return flag.DefaultText == "true" || flag.DefaultText == "false"
+}
+
+// isFlagToken reports whether a token names a flag; negative numbers are arguments
+func isFlagToken(token string) bool {
+	if len(token) < 2 || token[0] != '-' {
+		return false
+	}
+	_, err := strconv.ParseFloat(token, 64)
+	return err != nil
+}
+
+// validFlags lists the flags of a command for error messages
+func validFlags(info models.CommandInfo) string {
+	if len(info.Flags) == 0 {
+		return ", it takes no flags"
+	}
+
+	names := make([]string, 0, len(info.Flags))
+	for _, flag := range info.Flags {
+		names = append(names, "--"+flagNames(flag)[0])
+	}
+	return ", valid flags: " + strings.Join(names, ", ")
+}
@@ -0,0 +1,142 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/TutuanHo03/remote-control/models"
+
+	"github.com/abiosoft/ishell"
+)
+
+// jobPollInterval is the delay between two job state requests while watching
+const jobPollInterval = 500 * time.Millisecond
+
+// submitJob queues a command on the server instead of waiting for it
+func (c *Client) submitJob(cmdReq models.CommandRequest) (models.JobInfo, error) {
+	var job models.JobInfo
+	_, err := c.doJSON(context.Background(), http.MethodPost, "/api/jobs", cmdReq, &job)
+	return job, err
+}
+
+// getJob fetches the state of a job
+func (c *Client) getJob(ctx context.Context, id string) (models.JobInfo, error) {
+	var job models.JobInfo
+	_, err := c.doJSON(ctx, http.MethodGet, "/api/jobs/"+id, nil, &job)
+	return job, err
+}
+
+// jobsCmd builds the jobs command available once connected
+func (c *Client) jobsCmd() *ishell.Cmd {
+	return &ishell.Cmd{
+		Name:     "jobs",
+		Help:     "List and manage asynchronous jobs [jobs | jobs <id> | jobs watch <id> | jobs cancel <id>]",
+		LongHelp: "Commands run with --async are queued as jobs.\n  jobs               list all jobs\n  jobs <id>          show a job and its result\n  jobs watch <id>    wait for a job to finish (Ctrl-C stops watching)\n  jobs cancel <id>   cancel a pending or running job",
+		Completer: func(args []string) []string {
+			if len(args) > 0 {
+				return nil
+			}
+			return []string{"watch", "cancel"}
+		},
+		Func: func(ctx *ishell.Context) {
+			var err error
+			switch {
+			case len(ctx.Args) == 0:
+				err = c.listJobs()
+			case ctx.Args[0] == "watch" && len(ctx.Args) > 1:
+				err = c.watchJob(ctx.Args[1])
+			case ctx.Args[0] == "cancel" && len(ctx.Args) > 1:
+				var job models.JobInfo
+				_, err = c.doJSON(context.Background(), http.MethodDelete, "/api/jobs/"+ctx.Args[1], nil, &job)
+				if err == nil {
+					c.shell.Printf("Job %s: %s\n", job.ID, job.State)
+				}
+			default:
+				var job models.JobInfo
+				job, err = c.getJob(context.Background(), ctx.Args[0])
+				if err == nil {
+					c.printJob(job)
+				}
+			}
+			ctx.Err(err)
+		},
+	}
+}
+
+// listJobs prints a table of the jobs known to the server
+func (c *Client) listJobs() error {
+	var jobs []models.JobInfo
+	if _, err := c.doJSON(context.Background(), http.MethodGet, "/api/jobs", nil, &jobs); err != nil {
+		return err
+	}
+	if len(jobs) == 0 {
+		c.shell.Println("No jobs")
+		return nil
+	}
+
+	var sb strings.Builder
+	w := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tSTATE\tNODE\tCOMMAND\tCREATED")
+	for _, job := range jobs {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", job.ID, job.State,
+			job.Request.NodeType+" "+job.Request.NodeName, job.Request.CommandPath,
+			job.CreatedAt.Local().Format(time.TimeOnly))
+	}
+	w.Flush()
+	c.shell.Print(sb.String())
+	return nil
+}
+
+// watchJob polls a job until it finishes or the operator presses Ctrl-C
+func (c *Client) watchJob(id string) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	var last models.JobState
+	for {
+		job, err := c.getJob(ctx, id)
+		if err != nil {
+			if ctx.Err() != nil {
+				c.shell.Println("Stopped watching")
+				return nil
+			}
+			return err
+		}
+
+		if job.State != last {
+			c.shell.Printf("Job %s: %s\n", job.ID, job.State)
+			last = job.State
+		}
+		if job.State.Finished() {
+			c.printJob(job)
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			c.shell.Println("Stopped watching")
+			return nil
+		case <-time.After(jobPollInterval):
+		}
+	}
+}
+
+// printJob prints a job with its result once available
+func (c *Client) printJob(job models.JobInfo) {
+	c.shell.Printf("Job %s (%s %s %s): %s\n", job.ID, job.Request.NodeType,
+		job.Request.NodeName, job.Request.CommandPath, job.State)
+	if job.Response == nil {
+		return
+	}
+	if job.Response.Error != "" && job.Response.Result == nil {
+		c.shell.Printf("Error: %s\n", job.Response.Error)
+		return
+	}
+	c.shell.Println(c.renderResponse(*job.Response))
+}
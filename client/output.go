@@ -0,0 +1,98 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/TutuanHo03/remote-control/models"
+)
+
+// Output formats supported by the client
+const (
+	OutputText = "text"
+	OutputJSON = "json"
+)
+
+// SetOutputFormat selects how command results are rendered (text or json)
+func (c *Client) SetOutputFormat(format string) error {
+	switch format {
+	case OutputText, OutputJSON:
+		c.outputFormat = format
+		return nil
+	default:
+		return fmt.Errorf("unknown output format %q, use %s or %s", format, OutputText, OutputJSON)
+	}
+}
+
+// renderResponse formats a command response according to the output format
+func (c *Client) renderResponse(response models.CommandResponse) string {
+	// Servers without structured results only send the plain text
+	if response.Result == nil {
+		return response.Response
+	}
+
+	if c.outputFormat == OutputJSON {
+		data, err := json.MarshalIndent(response.Result, "", "  ")
+		if err != nil {
+			return response.Response
+		}
+		return string(data)
+	}
+
+	return renderResultText(response.Result)
+}
+
+// renderResultText renders a result as its message followed by the payload
+func renderResultText(result *models.CommandResult) string {
+	var sb strings.Builder
+	if result.Status != models.StatusSuccess && result.ErrorCode != "" {
+		sb.WriteString(fmt.Sprintf("[%s] ", result.ErrorCode))
+	}
+	sb.WriteString(result.Message)
+
+	keys := make([]string, 0, len(result.Data))
+	for key := range result.Data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	// Align the values on the longest key
+	width := 12
+	for _, key := range keys {
+		width = max(width, len(key)+1)
+	}
+	for _, key := range keys {
+		sb.WriteString(fmt.Sprintf("\n  %-*s %s", width, key+":", result.Data[key]))
+	}
+
+	if result.Table != nil && len(result.Table.Rows) > 0 {
+		sb.WriteString("\n")
+		sb.WriteString(renderTable(result.Table))
+	}
+
+	for _, fieldErr := range result.Errors {
+		sb.WriteString(fmt.Sprintf("\n  %s %s", fieldErr.Field, fieldErr.Message))
+	}
+
+	return sb.String()
+}
+
+// renderTable aligns the columns of a result table, indented like the data
+func renderTable(table *models.Table) string {
+	var sb strings.Builder
+	w := tabwriter.NewWriter(&sb, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "  %s\n", strings.Join(table.Columns, "\t"))
+	for _, row := range table.Rows {
+		fmt.Fprintf(w, "  %s\n", strings.Join(row, "\t"))
+	}
+	w.Flush()
+
+	lines := strings.Split(strings.TrimSuffix(sb.String(), "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " ")
+	}
+	return strings.Join(lines, "\n")
+}
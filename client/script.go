@@ -0,0 +1,230 @@
+package client
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+
+	"github.com/abiosoft/ishell"
+	"github.com/abiosoft/readline"
+)
+
+// ScriptOptions controls the execution of a script
+type ScriptOptions struct {
+	ContinueOnError bool              // Keep running the remaining lines after a failed command
+	Echo            bool              // Print each command with the prompt before running it
+	Vars            map[string]string // Initial variables, environment variables are used as fallback
+}
+
+// NewScriptClient creates a client for RunScript which never reads the terminal,
+// so the script itself can be read from stdin
+func NewScriptClient(out io.Writer) *Client {
+	shell := ishell.NewWithConfig(&readline.Config{
+		Stdin:  io.NopCloser(strings.NewReader("")),
+		Stdout: out,
+	})
+	return newClient(shell)
+}
+
+// RunScriptFile runs the script at path, "-" reads it from stdin
+func (c *Client) RunScriptFile(path string, opts ScriptOptions) error {
+	if path == "-" {
+		return c.RunScript(os.Stdin, opts)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return c.RunScript(f, opts)
+}
+
+// RunScript executes shell commands read line by line from r, through the same
+// navigation and exec paths as the interactive shell.
+//
+// Empty lines and lines starting with # are skipped, "set NAME value" defines a
+// variable and $NAME or ${NAME} is expanded in the arguments, except inside
+// single quotes. "exit" ends the script. The first failed command stops the script unless ContinueOnError is
+// set, in which case the failures are counted and reported at the end.
+func (c *Client) RunScript(r io.Reader, opts ScriptOptions) error {
+	vars := make(map[string]string, len(opts.Vars))
+	for k, v := range opts.Vars {
+		vars[k] = v
+	}
+	expand := func(name string) string {
+		if v, ok := vars[name]; ok {
+			return v
+		}
+		return os.Getenv(name)
+	}
+
+	failed := 0
+	lineNo := 0
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if opts.Echo {
+			c.shell.Printf("%s%s\n", c.prompt(), line)
+		}
+
+		args, err := splitScriptLine(line, expand)
+		if err != nil {
+			err = fmt.Errorf("line %d: %v", lineNo, err)
+		} else if len(args) > 0 {
+			err = c.runScriptLine(args, vars)
+			if err == errScriptExit {
+				break
+			}
+			if err != nil {
+				err = fmt.Errorf("line %d: %s: %v", lineNo, args[0], err)
+			}
+		}
+
+		if err != nil {
+			c.shell.Printf("Error: %v\n", err)
+			if !opts.ContinueOnError {
+				return err
+			}
+			failed++
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read script: %v", err)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d command(s) failed", failed)
+	}
+	return nil
+}
+
+// splitScriptLine splits a script line into words with shell-style quoting and
+// expands $NAME and ${NAME} outside single quotes. An expanded value stays in
+// the word it appears in, it is neither split nor unquoted again.
+func splitScriptLine(line string, expand func(string) string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord := false
+	quote := rune(0) // Opening quote while inside quotes
+
+	runes := []rune(line)
+scan:
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\\':
+			if i+1 == len(runes) {
+				return nil, errors.New("trailing backslash")
+			}
+			i++
+			// Inside double quotes the backslash only escapes \, " and $
+			if quote == '"' && !strings.ContainsRune(`\"$`, runes[i]) {
+				word.WriteRune('\\')
+			}
+			word.WriteRune(runes[i])
+		case r == '$':
+			name, n, err := scriptVarName(runes[i+1:])
+			if err != nil {
+				return nil, err
+			}
+			if n == 0 {
+				word.WriteRune(r)
+			} else {
+				word.WriteString(expand(name))
+				i += n
+			}
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case unicode.IsSpace(r):
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+			continue
+		case r == '#' && !inWord:
+			break scan
+		default:
+			word.WriteRune(r)
+		}
+		inWord = true
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+// scriptVarName reads the variable name following a $, as NAME or {NAME}, and
+// returns how many runes it spans; 0 when no name follows and $ is literal
+func scriptVarName(rest []rune) (string, int, error) {
+	if len(rest) > 0 && rest[0] == '{' {
+		for j, r := range rest {
+			if r == '}' {
+				return string(rest[1:j]), j + 1, nil
+			}
+		}
+		return "", 0, errors.New("unterminated ${")
+	}
+
+	n := 0
+	for n < len(rest) && (rest[n] == '_' || unicode.IsLetter(rest[n]) || unicode.IsDigit(rest[n])) {
+		n++
+	}
+	return string(rest[:n]), n, nil
+}
+
+// errScriptExit reports that the script ran the exit command
+var errScriptExit = errors.New("exit")
+
+// runScriptLine runs one tokenized script line
+func (c *Client) runScriptLine(args []string, vars map[string]string) error {
+	switch args[0] {
+	case "exit":
+		return errScriptExit
+	case "set":
+		if len(args) < 2 {
+			return fmt.Errorf("usage: set <name> [value]")
+		}
+		vars[args[1]] = strings.Join(args[2:], " ")
+		return nil
+	}
+
+	return c.shell.Process(args...)
+}
+
+// prompt returns the shell prompt of the current context
+func (c *Client) prompt() string {
+	current := c.getCurrentContext()
+	if current.Type == "root" || current.Type == "server" {
+		return ">>> "
+	}
+	return current.Name + " >>> "
+}
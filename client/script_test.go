@@ -0,0 +1,213 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/TutuanHo03/remote-control/models"
+
+	"github.com/abiosoft/ishell"
+)
+
+func TestSplitScriptLine(t *testing.T) {
+	vars := map[string]string{"UE": "imsi-001010000000001", "DN": "my dn", "EMPTY": ""}
+	expand := func(name string) string { return vars[name] }
+
+	tests := []struct {
+		line    string
+		want    []string
+		wantErr string
+	}{
+		{line: "select ue $UE", want: []string{"select", "ue", "imsi-001010000000001"}},
+		{line: "select ue ${UE}", want: []string{"select", "ue", "imsi-001010000000001"}},
+		{line: "create-session --dn $DN", want: []string{"create-session", "--dn", "my dn"}},
+		{line: `create-session --dn="$DN-2"`, want: []string{"create-session", "--dn=my dn-2"}},
+		{line: "echo '$UE ${DN}'", want: []string{"echo", "$UE ${DN}"}},
+		{line: `echo "'$UE'"`, want: []string{"echo", "'imsi-001010000000001'"}},
+		{line: `echo '"$UE"'`, want: []string{"echo", `"$UE"`}},
+		{line: `echo \$UE "\$UE" "a\b"`, want: []string{"echo", "$UE", "$UE", `a\b`}},
+		{line: "echo $ 5$ $-", want: []string{"echo", "$", "5$", "$-"}},
+		{line: "echo $EMPTY '' x", want: []string{"echo", "", "", "x"}},
+		{line: "echo a\\ b  c\t# comment $UE", want: []string{"echo", "a b", "c"}},
+		{line: "echo a#b", want: []string{"echo", "a#b"}},
+		{line: "echo 'unterminated", wantErr: "unterminated ' quote"},
+		{line: `echo "unterminated`, wantErr: `unterminated " quote`},
+		{line: "echo ${UE", wantErr: "unterminated ${"},
+		{line: `echo \`, wantErr: "trailing backslash"},
+	}
+
+	for _, tc := range tests {
+		words, err := splitScriptLine(tc.line, expand)
+		if tc.wantErr != "" {
+			if err == nil || err.Error() != tc.wantErr {
+				t.Errorf("%s: got error %v, want %q", tc.line, err, tc.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tc.line, err)
+			continue
+		}
+		if !slices.Equal(words, tc.want) {
+			t.Errorf("%s: got %q, want %q", tc.line, words, tc.want)
+		}
+	}
+}
+
+// newTestScriptClient returns a script client with an echo command printing
+// its arguments quoted, and the ue session group
+func newTestScriptClient() (*Client, *bytes.Buffer) {
+	out := &bytes.Buffer{}
+	c := NewScriptClient(out)
+	c.shell.AddCmd(&ishell.Cmd{
+		Name: "echo",
+		Func: func(ctx *ishell.Context) {
+			ctx.Println(fmt.Sprintf("%q", ctx.Args))
+		},
+	})
+	c.shell.AddCmd(c.newNodeCmd(models.ClientContext{Type: "node", NodeType: "ue", Name: "imsi-001010000000001"}, "session", models.CommandInfo{
+		Name:  "session",
+		Usage: "PDU session commands",
+		Subcommands: []models.CommandInfo{
+			{Name: "create", Usage: "Create a new session"},
+			{Name: "list", Usage: "List the sessions"},
+		},
+	}))
+	return c, out
+}
+
+func TestRunScript(t *testing.T) {
+	tests := []struct {
+		name    string
+		script  string
+		opts    ScriptOptions
+		want    []string // Lines expected in the output, in order
+		notWant []string // Lines of commands that must not run
+		wantErr string
+	}{
+		{
+			name:   "variables outside single quotes",
+			script: "set NAME world\necho $NAME '$NAME' \"${NAME}s\"\n",
+			want:   []string{`["world" "$NAME" "worlds"]`},
+		},
+		{
+			name:   "initial variables and comments",
+			script: "# greeting\n\necho $GREETING\n",
+			opts:   ScriptOptions{Vars: map[string]string{"GREETING": "hello there"}},
+			want:   []string{`["hello there"]`},
+		},
+		{
+			name:    "group without subcommand",
+			script:  "session\necho unreachable\n",
+			want:    []string{"Commands:", "Error: line 1: session: incomplete command"},
+			notWant: []string{"unreachable"},
+			wantErr: `incomplete command "session"`,
+		},
+		{
+			name:    "group with an unknown subcommand",
+			script:  "session destroy\n",
+			wantErr: `unknown command "session destroy"`,
+		},
+		{
+			name:   "group help",
+			script: "session --help\n",
+			want:   []string{"Commands:"},
+		},
+		{
+			name:    "failures counted when continuing",
+			script:  "session\necho after\nsession bogus\n",
+			opts:    ScriptOptions{ContinueOnError: true},
+			want:    []string{`["after"]`},
+			wantErr: "2 command(s) failed",
+		},
+		{
+			name:    "quoting error",
+			script:  "echo 'open\n",
+			wantErr: "line 1: unterminated ' quote",
+		},
+		{
+			name:    "exit",
+			script:  "echo before\nexit\necho after\n",
+			want:    []string{`["before"]`},
+			notWant: []string{`["after"]`},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c, out := newTestScriptClient()
+			err := c.RunScript(strings.NewReader(tc.script), tc.opts)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("got error %v, want one containing %q", err, tc.wantErr)
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			}
+
+			output := out.String()
+			for _, want := range tc.want {
+				i := strings.Index(output, want)
+				if i < 0 {
+					t.Fatalf("output %q misses %q", out.String(), want)
+				}
+				output = output[i+len(want):]
+			}
+			for _, notWant := range tc.notWant {
+				if strings.Contains(out.String(), notWant) {
+					t.Errorf("output %q has %q", out.String(), notWant)
+				}
+			}
+		})
+	}
+}
+
+func TestRunScriptLeavesNodeCommands(t *testing.T) {
+	ueSet := models.ClientContext{Type: "context_set", Name: "ue", ParentPath: "server", NodeType: "ue"}
+	node := models.ClientContext{Type: "node", Name: "imsi-001010000000001", ParentPath: "ue", NodeType: "ue"}
+
+	executed := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/context/navigate":
+			var req models.NavigationRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			response := models.NavigationResponse{Context: ueSet}
+			if req.Command == "select" {
+				response = models.NavigationResponse{Context: node, Commands: []models.CommandInfo{{Name: "register", Usage: "Register UE to the network"}}}
+			}
+			json.NewEncoder(w).Encode(response)
+		case "/api/exec":
+			executed++
+			json.NewEncoder(w).Encode(models.CommandResponse{
+				Response: "UE registered",
+				Result:   &models.CommandResult{Status: models.StatusSuccess, Message: "UE registered"},
+			})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	out := &bytes.Buffer{}
+	c := NewScriptClient(out)
+	c.serverURL = ts.URL
+	c.sessionID = "session"
+	c.contextStack = append(c.contextStack, models.ClientContext{Type: "server", Name: "server", ParentPath: "root"}, ueSet)
+	c.setupCommands("context_set")
+
+	script := "select imsi-001010000000001\nregister\nback\nregister\n"
+	err := c.RunScript(strings.NewReader(script), ScriptOptions{})
+	if err == nil || !strings.HasPrefix(err.Error(), "line 4: register:") {
+		t.Errorf("got error %v, want line 4 to fail", err)
+	}
+	if executed != 1 {
+		t.Errorf("register ran %d times, want once, on the selected node", executed)
+	}
+}
@@ -0,0 +1,94 @@
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// TLSOptions - TLS settings of the connections to HTTPS servers
+type TLSOptions struct {
+	CAFile             string // PEM bundle of the CAs trusted for the server certificate, system CAs when empty
+	CertFile           string // PEM client certificate for servers requiring mutual TLS
+	KeyFile            string // PEM private key of the client certificate
+	InsecureSkipVerify bool   // Accept any server certificate, for lab setups only
+}
+
+// enabled reports whether any TLS setting is given
+func (o TLSOptions) enabled() bool {
+	return o.CAFile != "" || o.CertFile != "" || o.KeyFile != "" || o.InsecureSkipVerify
+}
+
+// config builds the tls.Config of the options
+func (o TLSOptions) config() (*tls.Config, error) {
+	config := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: o.InsecureSkipVerify,
+	}
+
+	if o.CAFile != "" {
+		data, err := os.ReadFile(o.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA bundle: %v", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("no PEM certificate found in %s", o.CAFile)
+		}
+		config.RootCAs = pool
+	}
+
+	if o.CertFile != "" || o.KeyFile != "" {
+		if o.CertFile == "" || o.KeyFile == "" {
+			return nil, fmt.Errorf("a client certificate requires both a certificate and a key file")
+		}
+		cert, err := tls.LoadX509KeyPair(o.CertFile, o.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %v", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config, nil
+}
+
+// newHTTPClient returns the HTTP client of the options, the default client
+// when no TLS setting is given
+func newHTTPClient(opts TLSOptions) (*http.Client, error) {
+	if !opts.enabled() {
+		return http.DefaultClient, nil
+	}
+
+	config, err := opts.config()
+	if err != nil {
+		return nil, err
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = config
+	return &http.Client{Transport: transport}, nil
+}
+
+// SetTLS configures the certificates used to reach HTTPS servers; server URLs
+// without a scheme then default to https
+func (c *Client) SetTLS(opts TLSOptions) error {
+	httpClient, err := newHTTPClient(opts)
+	if err != nil {
+		return err
+	}
+	c.httpClient = httpClient
+	c.tls = opts.enabled()
+	return nil
+}
+
+// normalizeURL adds the default scheme to a server URL without one
+func normalizeURL(url string, useTLS bool) string {
+	if strings.HasPrefix(url, "http://") || strings.HasPrefix(url, "https://") {
+		return url
+	}
+	if useTLS {
+		return "https://" + url
+	}
+	return "http://" + url
+}
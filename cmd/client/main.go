@@ -0,0 +1,16 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/TutuanHo03/remote-control/client"
+)
+
+func main() {
+	if err := client.NewCommand().Run(context.Background(), os.Args); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(client.ExitUsage)
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/TutuanHo03/remote-control/mock"
+	"github.com/TutuanHo03/remote-control/server"
+
+	"github.com/urfave/cli/v3"
+)
+
+func main() {
+	cmd := &cli.Command{
+		Name:  "remote-control-server",
+		Usage: "Serve the remote control API of an emulator",
+		Description: "Settings are read from the configuration file, YAML or TOML, and the REMOTE_CONTROL_* environment variables.\n" +
+			"Without an emulator backend the built-in mock emulator is served.",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "config",
+				Aliases: []string{"c"},
+				Usage:   "Configuration file, .yaml, .yml or .toml",
+				Sources: cli.EnvVars(server.EnvPrefix + "CONFIG"),
+			},
+		},
+		Action: run,
+	}
+
+	if err := cmd.Run(context.Background(), os.Args); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// run loads the configuration, wires the emulator and serves until a signal
+func run(ctx context.Context, cmd *cli.Command) error {
+	config, err := server.LoadConfig(cmd.String("config"))
+	if err != nil {
+		return err
+	}
+
+	serverConfig, err := config.ServerConfig()
+	if err != nil {
+		return err
+	}
+
+	switch config.Emulator.Backend {
+	case "", "mock":
+	default:
+		return fmt.Errorf("unknown emulator backend %q, only the mock backend is built in; embed the server package to serve another emulator", config.Emulator.Backend)
+	}
+
+	mockConfig := mock.DefaultConfig()
+	if len(config.Emulator.Ues) > 0 {
+		mockConfig.Ues = config.Emulator.Ues
+	}
+	if len(config.Emulator.Gnbs) > 0 {
+		mockConfig.Gnbs = config.Emulator.Gnbs
+	}
+	if config.Emulator.Plmn != "" {
+		mockConfig.Plmn = config.Emulator.Plmn
+	}
+	emulator := mock.NewEmulator(mockConfig)
+	log.Printf("Serving the mock emulator with %d UE(s) and %d gNB(s)", len(mockConfig.Ues), len(mockConfig.Gnbs))
+
+	return server.NewServer(serverConfig, emulator, emulator, emulator).Run(ctx)
+}
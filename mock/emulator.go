@@ -0,0 +1,265 @@
+// Package mock provides an in-memory emulator of UEs and gNBs, for trying
+// the remote control server and its clients without a real backend
+package mock
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/TutuanHo03/remote-control/models"
+	"github.com/TutuanHo03/remote-control/server/handlers"
+)
+
+// Config - Initial nodes of the mock emulator
+type Config struct {
+	Ues  []string // SUPIs of the UEs, imsi-<15 digits>
+	Gnbs []string // Names of the gNBs
+	Plmn string   // MCC-MNC of every node, 001-01 when empty
+}
+
+// DefaultConfig returns a small network of two gNBs and three UEs
+func DefaultConfig() Config {
+	return Config{
+		Ues:  []string{"imsi-001010000000001", "imsi-001010000000002", "imsi-001010000000003"},
+		Gnbs: []string{"gnb1", "gnb2"},
+		Plmn: "001-01",
+	}
+}
+
+// Emulator - Mock emulator implementing every API of the control server;
+// procedures always succeed and only change the in-memory state
+type Emulator struct {
+	mu          sync.Mutex
+	plmn        string
+	ues         map[string]*Ue
+	gnbs        map[string]*Gnb
+	nextNgapID  int64
+	nextGnbID   int
+	subscribers map[int]func(models.NodeEvent)
+	nextSubID   int
+	changed     []func()
+}
+
+// NewEmulator creates a mock emulator with the nodes of config
+func NewEmulator(config Config) *Emulator {
+	e := &Emulator{
+		plmn:        config.Plmn,
+		ues:         make(map[string]*Ue),
+		gnbs:        make(map[string]*Gnb),
+		subscribers: make(map[int]func(models.NodeEvent)),
+	}
+	if e.plmn == "" {
+		e.plmn = "001-01"
+	}
+	for _, name := range config.Gnbs {
+		e.gnbs[name] = e.newGnb(name)
+	}
+	for _, supi := range config.Ues {
+		e.ues[supi] = e.newUe(supi)
+	}
+	return e
+}
+
+// ListUes implements handlers.EmulatorApi
+func (e *Emulator) ListUes() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return sortedKeys(e.ues)
+}
+
+// ListGnbs implements handlers.EmulatorApi
+func (e *Emulator) ListGnbs() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return sortedKeys(e.gnbs)
+}
+
+// AddUe implements handlers.EmulatorApi
+func (e *Emulator) AddUe(supi string, triggerRegister bool) bool {
+	e.mu.Lock()
+	if _, exists := e.ues[supi]; exists {
+		e.mu.Unlock()
+		return false
+	}
+	ue := e.newUe(supi)
+	e.ues[supi] = ue
+	e.mu.Unlock()
+
+	e.nodesChanged()
+	if triggerRegister {
+		ue.Register(false)
+	}
+	return true
+}
+
+// RemoveUe implements handlers.NodeProvisioner
+func (e *Emulator) RemoveUe(supi string) bool {
+	e.mu.Lock()
+	ue, exists := e.ues[supi]
+	if !exists {
+		e.mu.Unlock()
+		return false
+	}
+	delete(e.ues, supi)
+	ue.detachLocked()
+	e.mu.Unlock()
+
+	e.nodesChanged()
+	return true
+}
+
+// AddGnb implements handlers.NodeProvisioner
+func (e *Emulator) AddGnb(name string, triggerNgSetup bool) bool {
+	e.mu.Lock()
+	if _, exists := e.gnbs[name]; exists {
+		e.mu.Unlock()
+		return false
+	}
+	gnb := e.newGnb(name)
+	if !triggerNgSetup {
+		gnb.ngState = "not-started"
+	}
+	e.gnbs[name] = gnb
+	e.mu.Unlock()
+
+	e.nodesChanged()
+	return true
+}
+
+// RemoveGnb implements handlers.NodeProvisioner; its UEs lose their connection
+func (e *Emulator) RemoveGnb(name string) bool {
+	e.mu.Lock()
+	gnb, exists := e.gnbs[name]
+	if !exists {
+		e.mu.Unlock()
+		return false
+	}
+	delete(e.gnbs, name)
+	for _, ue := range gnb.ues {
+		ue.gnb = nil
+		ue.cmState = "CM-IDLE"
+	}
+	e.mu.Unlock()
+
+	e.nodesChanged()
+	return true
+}
+
+// GetUe implements handlers.UeProvider
+func (e *Emulator) GetUe(name string) (handlers.UeApi, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	ue, exists := e.ues[name]
+	if !exists {
+		return nil, fmt.Errorf("UE %s does not exist", name)
+	}
+	return ue, nil
+}
+
+// GetGnb implements handlers.GnbProvider
+func (e *Emulator) GetGnb(name string) (handlers.GnbApi, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	gnb, exists := e.gnbs[name]
+	if !exists {
+		return nil, fmt.Errorf("gNB %s does not exist", name)
+	}
+	return gnb, nil
+}
+
+// Subscribe implements handlers.EventSource
+func (e *Emulator) Subscribe(handler func(models.NodeEvent)) (unsubscribe func()) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	id := e.nextSubID
+	e.nextSubID++
+	e.subscribers[id] = handler
+	return func() {
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		delete(e.subscribers, id)
+	}
+}
+
+// OnNodesChanged implements handlers.NodeChangeNotifier
+func (e *Emulator) OnNodesChanged(callback func()) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.changed = append(e.changed, callback)
+}
+
+// publish sends an event to the subscribers, outside of the emulator lock
+func (e *Emulator) publish(eventType models.EventType, nodeType, nodeName string, details map[string]string) {
+	e.mu.Lock()
+	subscribers := make([]func(models.NodeEvent), 0, len(e.subscribers))
+	for _, handler := range e.subscribers {
+		subscribers = append(subscribers, handler)
+	}
+	e.mu.Unlock()
+
+	event := models.NodeEvent{
+		Type:     eventType,
+		NodeType: nodeType,
+		NodeName: nodeName,
+		Time:     time.Now(),
+		Details:  details,
+	}
+	for _, handler := range subscribers {
+		handler(event)
+	}
+}
+
+// nodesChanged runs the OnNodesChanged callbacks, outside of the emulator
+// lock; the node added and removed events come from the node sync
+func (e *Emulator) nodesChanged() {
+	e.mu.Lock()
+	changed := append([]func(){}, e.changed...)
+	e.mu.Unlock()
+
+	for _, callback := range changed {
+		callback()
+	}
+}
+
+// newUe creates a deregistered, idle UE
+func (e *Emulator) newUe(supi string) *Ue {
+	return &Ue{
+		e:       e,
+		supi:    supi,
+		rmState: "RM-DEREGISTERED",
+		cmState: "CM-IDLE",
+	}
+}
+
+// newGnb creates a gNB with an established NG association to a mock AMF
+func (e *Emulator) newGnb(name string) *Gnb {
+	e.nextGnbID++
+	return &Gnb{
+		e:       e,
+		name:    name,
+		id:      e.nextGnbID,
+		ngState: "established",
+		ues:     make(map[string]*Ue),
+	}
+}
+
+// firstGnbLocked returns the gNB UEs attach to, nil without gNBs
+func (e *Emulator) firstGnbLocked() *Gnb {
+	names := sortedKeys(e.gnbs)
+	if len(names) == 0 {
+		return nil
+	}
+	return e.gnbs[names[0]]
+}
+
+// sortedKeys returns the keys of a node map in order
+func sortedKeys[T any](nodes map[string]T) []string {
+	names := make([]string, 0, len(nodes))
+	for name := range nodes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
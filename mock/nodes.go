@@ -0,0 +1,317 @@
+package mock
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+
+	"github.com/TutuanHo03/remote-control/models"
+)
+
+// Ue - Mock UE, registered and connected through the first gNB
+type Ue struct {
+	e        *Emulator
+	supi     string
+	rmState  string
+	cmState  string
+	gnb      *Gnb
+	ngapID   int64
+	sessions []models.PduSession
+}
+
+// sessionTypes names the PDU session types of create-session; 0, the default
+// of its --type flag, lets the network pick and gets IPv4
+var sessionTypes = map[uint8]string{0: "IPv4", 1: "IPv4", 2: "IPv6", 3: "IPv4v6"}
+
+// Register implements handlers.UeApi; it fails without a gNB to attach to
+func (u *Ue) Register(isEmergency bool) bool {
+	u.e.mu.Lock()
+	gnb := u.gnb
+	if gnb == nil {
+		gnb = u.e.firstGnbLocked()
+	}
+	if gnb == nil || gnb.ngState != "established" {
+		u.e.mu.Unlock()
+		return false
+	}
+	u.attachLocked(gnb)
+	u.rmState = "RM-REGISTERED"
+	u.e.mu.Unlock()
+
+	u.e.publish(models.EventRegistered, "ue", u.supi, map[string]string{
+		"emergency": strconv.FormatBool(isEmergency),
+		"gnb":       gnb.name,
+	})
+	return true
+}
+
+// Deregister implements handlers.UeApi
+func (u *Ue) Deregister(deregisterType uint8) bool {
+	u.e.mu.Lock()
+	if u.rmState != "RM-REGISTERED" {
+		u.e.mu.Unlock()
+		return false
+	}
+	u.detachLocked()
+	u.rmState = "RM-DEREGISTERED"
+	u.sessions = nil
+	u.e.mu.Unlock()
+
+	u.e.publish(models.EventDeregistered, "ue", u.supi, map[string]string{
+		"type": strconv.Itoa(int(deregisterType)),
+	})
+	return true
+}
+
+// CreateSession implements handlers.UeApi; the UE must be registered
+func (u *Ue) CreateSession(slice string, dnName string, sessionType uint8) bool {
+	typeName, ok := sessionTypes[sessionType]
+	if !ok {
+		return false
+	}
+
+	u.e.mu.Lock()
+	if u.rmState != "RM-REGISTERED" || len(u.sessions) >= 15 {
+		u.e.mu.Unlock()
+		return false
+	}
+	id := uint8(1)
+	for _, session := range u.sessions {
+		if session.ID >= id {
+			id = session.ID + 1
+		}
+	}
+	session := models.PduSession{
+		ID:      id,
+		Dnn:     dnName,
+		Slice:   slice,
+		Type:    typeName,
+		State:   "active",
+		Address: fmt.Sprintf("10.45.%d.%d", u.ngapID%250+1, id),
+	}
+	u.sessions = append(u.sessions, session)
+	if u.gnb == nil {
+		u.attachLocked(u.e.firstGnbLocked())
+	}
+	u.e.mu.Unlock()
+
+	u.e.publish(models.EventSessionUp, "ue", u.supi, map[string]string{
+		"sessionId": strconv.Itoa(int(id)),
+		"dnn":       dnName,
+		"slice":     slice,
+	})
+	return true
+}
+
+// ReleaseSession implements handlers.SessionReleaser
+func (u *Ue) ReleaseSession(sessionId uint8) error {
+	u.e.mu.Lock()
+	index := slices.IndexFunc(u.sessions, func(session models.PduSession) bool { return session.ID == sessionId })
+	if index < 0 {
+		u.e.mu.Unlock()
+		return fmt.Errorf("no PDU session %d", sessionId)
+	}
+	u.sessions = slices.Delete(u.sessions, index, index+1)
+	u.e.mu.Unlock()
+
+	u.e.publish(models.EventSessionDown, "ue", u.supi, map[string]string{
+		"sessionId": strconv.Itoa(int(sessionId)),
+	})
+	return nil
+}
+
+// Status implements handlers.UeInspector
+func (u *Ue) Status() (models.UeStatus, error) {
+	u.e.mu.Lock()
+	defer u.e.mu.Unlock()
+
+	status := models.UeStatus{RmState: u.rmState, CmState: u.cmState}
+	if u.rmState == "RM-REGISTERED" {
+		status.Guti = fmt.Sprintf("5g-guti-%s-cafe%08d", u.e.plmn, u.ngapID)
+		status.AllowedNssai = []string{"1:010203"}
+		status.Security = models.SecurityContext{Active: true, Ngksi: 1, CipheringAlg: "NEA2", IntegrityAlg: "NIA2"}
+	}
+	return status, nil
+}
+
+// Sessions implements handlers.UeInspector
+func (u *Ue) Sessions() ([]models.PduSession, error) {
+	u.e.mu.Lock()
+	defer u.e.mu.Unlock()
+	return append([]models.PduSession(nil), u.sessions...), nil
+}
+
+// Config implements handlers.UeInspector
+func (u *Ue) Config() (models.UeConfig, error) {
+	u.e.mu.Lock()
+	defer u.e.mu.Unlock()
+
+	config := models.UeConfig{
+		Supi:            u.supi,
+		Plmn:            u.e.plmn,
+		ConfiguredNssai: []string{"1:010203", "1:112233"},
+		DefaultDnn:      "internet",
+		IntegrityAlgs:   []string{"NIA2", "NIA1"},
+		CipheringAlgs:   []string{"NEA2", "NEA1", "NEA0"},
+	}
+	for _, name := range sortedKeys(u.e.gnbs) {
+		config.GnbSearchList = append(config.GnbSearchList, u.e.gnbs[name].ngapAddress())
+	}
+	return config, nil
+}
+
+// attachLocked connects the UE through a gNB
+func (u *Ue) attachLocked(gnb *Gnb) {
+	if gnb == nil || u.gnb == gnb {
+		return
+	}
+	u.detachLocked()
+	u.e.nextNgapID++
+	u.ngapID = u.e.nextNgapID
+	u.gnb = gnb
+	u.cmState = "CM-CONNECTED"
+	gnb.ues[u.supi] = u
+}
+
+// detachLocked releases the connection of the UE, keeping its registration
+func (u *Ue) detachLocked() {
+	if u.gnb != nil {
+		delete(u.gnb.ues, u.supi)
+	}
+	u.gnb = nil
+	u.cmState = "CM-IDLE"
+}
+
+// Gnb - Mock gNB connected to a single mock AMF
+type Gnb struct {
+	e       *Emulator
+	name    string
+	id      int
+	ngState string
+	ues     map[string]*Ue
+}
+
+// ReleaseUe implements handlers.GnbApi, the UE stays registered but idle
+func (g *Gnb) ReleaseUe(ueId string) bool {
+	g.e.mu.Lock()
+	ue, connected := g.ues[ueId]
+	if !connected {
+		g.e.mu.Unlock()
+		return false
+	}
+	ue.detachLocked()
+	g.e.mu.Unlock()
+
+	g.e.publish(models.EventUeReleased, "gnb", g.name, map[string]string{"ueId": ueId})
+	return true
+}
+
+// ReleaseSession implements handlers.GnbApi
+func (g *Gnb) ReleaseSession(ueId string, sessionId uint8) bool {
+	g.e.mu.Lock()
+	ue, connected := g.ues[ueId]
+	if !connected {
+		g.e.mu.Unlock()
+		return false
+	}
+	released := false
+	for i, session := range ue.sessions {
+		if session.ID == sessionId {
+			ue.sessions = append(ue.sessions[:i], ue.sessions[i+1:]...)
+			released = true
+			break
+		}
+	}
+	g.e.mu.Unlock()
+
+	if released {
+		g.e.publish(models.EventSessionDown, "ue", ueId, map[string]string{
+			"sessionId": strconv.Itoa(int(sessionId)),
+			"gnb":       g.name,
+		})
+	}
+	return released
+}
+
+// ConnectedUes implements handlers.GnbInspector
+func (g *Gnb) ConnectedUes() ([]models.GnbUe, error) {
+	g.e.mu.Lock()
+	defer g.e.mu.Unlock()
+
+	ues := make([]models.GnbUe, 0, len(g.ues))
+	for _, ue := range g.ues {
+		ues = append(ues, models.GnbUe{
+			UeID:        ue.supi,
+			Supi:        ue.supi,
+			RanUeNgapID: ue.ngapID,
+			AmfUeNgapID: 1000 + ue.ngapID,
+			State:       "connected",
+		})
+	}
+	sort.Slice(ues, func(i, j int) bool { return ues[i].RanUeNgapID < ues[j].RanUeNgapID })
+	return ues, nil
+}
+
+// NgStatus implements handlers.GnbInspector
+func (g *Gnb) NgStatus() (models.NgStatus, error) {
+	g.e.mu.Lock()
+	defer g.e.mu.Unlock()
+
+	amfState := "disconnected"
+	if g.ngState == "established" {
+		amfState = "connected"
+	}
+	return models.NgStatus{
+		State: g.ngState,
+		Amfs: []models.AmfLink{
+			{Name: "amf1", Address: "127.0.0.18:38412", State: amfState, Capacity: 255},
+		},
+	}, nil
+}
+
+// Config implements handlers.GnbInspector
+func (g *Gnb) Config() (models.GnbConfig, error) {
+	g.e.mu.Lock()
+	defer g.e.mu.Unlock()
+
+	return models.GnbConfig{
+		GnbID:       fmt.Sprintf("%06x", g.id),
+		Plmns:       []string{g.e.plmn},
+		Tacs:        []string{"1"},
+		Slices:      []string{"1:010203", "1:112233"},
+		NgapAddress: g.ngapAddress(),
+		GtpAddress:  fmt.Sprintf("127.0.1.%d:2152", g.id),
+	}, nil
+}
+
+// NgSetup implements handlers.NgController
+func (g *Gnb) NgSetup(amf string) error {
+	g.e.mu.Lock()
+	defer g.e.mu.Unlock()
+
+	if amf != "" && amf != "amf1" {
+		return fmt.Errorf("unknown AMF %s", amf)
+	}
+	g.ngState = "established"
+	return nil
+}
+
+// NgReset implements handlers.NgController; the UE contexts are released
+func (g *Gnb) NgReset(amf string) error {
+	g.e.mu.Lock()
+	defer g.e.mu.Unlock()
+
+	if amf != "" && amf != "amf1" {
+		return fmt.Errorf("unknown AMF %s", amf)
+	}
+	for _, ue := range g.ues {
+		ue.detachLocked()
+	}
+	return nil
+}
+
+// ngapAddress returns the NGAP address of the gNB
+func (g *Gnb) ngapAddress() string {
+	return fmt.Sprintf("127.0.1.%d:38412", g.id)
+}
@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// AuditSource - API call that led to an audit record
+type AuditSource string
+
+const (
+	AuditExec     AuditSource = "exec"     // POST /api/exec
+	AuditBulk     AuditSource = "bulk"     // One node of POST /api/bulk
+	AuditJob      AuditSource = "job"      // An asynchronous job
+	AuditNavigate AuditSource = "navigate" // POST /api/context/navigate
+)
+
+// AuditRecord - Record of an executed command or navigation call
+type AuditRecord struct {
+	Time        time.Time         `json:"time"`
+	Source      AuditSource       `json:"source"`
+	SessionID   string            `json:"sessionId,omitempty"`
+	User        string            `json:"user,omitempty"` // Subject of the caller when authentication is on
+	Role        string            `json:"role,omitempty"`
+	ClientIP    string            `json:"clientIp,omitempty"`
+	NodeType    string            `json:"nodeType,omitempty"`
+	NodeName    string            `json:"nodeName,omitempty"`
+	CommandPath string            `json:"commandPath"` // Navigation command for navigate records
+	Args        []string          `json:"args,omitempty"`
+	Flags       map[string]string `json:"flags,omitempty"`
+	Outcome     ResultStatus      `json:"outcome"`
+	ErrorCode   string            `json:"errorCode,omitempty"`
+	Message     string            `json:"message,omitempty"`
+	DurationMs  int64             `json:"durationMs"`
+}
@@ -1,11 +1,14 @@
 package models
 
+import "time"
+
 // CommandInfo - Define the structure command same as server
 type CommandInfo struct {
 	Name        string        `json:"name"`
 	Usage       string        `json:"usage"`
 	Description string        `json:"description"`
 	ArgsUsage   string        `json:"argsUsage"`
+	Args        []ArgInfo     `json:"args,omitempty"`
 	Flags       []FlagInfo    `json:"flags"`
 	Subcommands []CommandInfo `json:"subcommands,omitempty"`
 }
@@ -13,13 +16,46 @@ type CommandInfo struct {
 // FlagInfo - Define the structure of flag same as server
 type FlagInfo struct {
 	Name        string `json:"name"`
+	Type        string `json:"type,omitempty"`
 	Usage       string `json:"usage"`
 	DefaultText string `json:"defaultText,omitempty"`
 	Required    bool   `json:"required"`
+	ValueRules
+}
+
+// ArgInfo - Define the structure of a positional argument
+type ArgInfo struct {
+	Name     string `json:"name"`
+	Type     string `json:"type,omitempty"`
+	Usage    string `json:"usage"`
+	Required bool   `json:"required"`
+	ValueRules
+}
+
+// ValueRules - Constraints on the value of a flag or argument
+type ValueRules struct {
+	Enum    []string `json:"enum,omitempty"`    // Allowed values
+	Min     *int64   `json:"min,omitempty"`     // Smallest allowed integer
+	Max     *int64   `json:"max,omitempty"`     // Largest allowed integer
+	Pattern string   `json:"pattern,omitempty"` // Regular expression the value must match
+}
+
+// FieldError - Validation error of one flag or argument of a request
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
 }
 
+// Value types of FlagInfo.Type
+const (
+	FlagTypeString = "string"
+	FlagTypeBool   = "bool"
+	FlagTypeInt    = "int"
+)
+
 // CommandRequest - Structure demand same as server
 type CommandRequest struct {
+	SessionID   string            `json:"sessionId,omitempty"`
 	NodeType    string            `json:"nodeType"`
 	NodeName    string            `json:"nodeName"`
 	CommandPath string            `json:"commandPath"`
@@ -30,12 +66,15 @@ type CommandRequest struct {
 
 // CommandResponse - Structure response same as server
 type CommandResponse struct {
-	Response string `json:"response"`
-	Error    string `json:"error"`
+	Response string         `json:"response"`
+	Error    string         `json:"error"`
+	Result   *CommandResult `json:"result,omitempty"`
+	Notices  []string       `json:"notices,omitempty"`
 }
 
 // NavigationRequest - Structure of request navigation
 type NavigationRequest struct {
+	SessionID      string   `json:"sessionId,omitempty"`
 	CurrentContext string   `json:"currentContext"`
 	Command        string   `json:"command"`
 	Args           []string `json:"args"`
@@ -45,11 +84,13 @@ type NavigationRequest struct {
 
 // NavigationResponse - Structure of response navigation
 type NavigationResponse struct {
-	Context  ClientContext `json:"context"`
-	Prompt   string        `json:"prompt"`
-	Message  string        `json:"message"`
-	Commands []CommandInfo `json:"commands"`
-	Error    string        `json:"error"`
+	SessionID string        `json:"sessionId,omitempty"`
+	Context   ClientContext `json:"context"`
+	Prompt    string        `json:"prompt"`
+	Message   string        `json:"message"`
+	Commands  []CommandInfo `json:"commands"`
+	Notices   []string      `json:"notices,omitempty"`
+	Error     string        `json:"error"`
 }
 
 // ClientContext - Structure of client context
@@ -63,3 +104,68 @@ type ClientContext struct {
 	NodeType      string   `json:"nodeType"`
 	Commands      []string `json:"commands"`
 }
+
+// SessionInfo - Structure of an operator session as listed by the server
+type SessionInfo struct {
+	ID          string    `json:"id"`
+	RemoteAddr  string    `json:"remoteAddr"`
+	ServerURL   string    `json:"serverURL"`
+	User        string    `json:"user,omitempty"` // Subject of the caller that connected when authentication is on
+	CreatedAt   time.Time `json:"createdAt"`
+	LastActive  time.Time `json:"lastActive"`
+	Path        []string  `json:"path"`
+	ContextType string    `json:"contextType"`
+	NodeType    string    `json:"nodeType,omitempty"`
+	NodeName    string    `json:"nodeName,omitempty"`
+}
+
+// JobState - Lifecycle state of an asynchronous job
+type JobState string
+
+const (
+	JobPending   JobState = "pending"
+	JobRunning   JobState = "running"
+	JobSucceeded JobState = "succeeded"
+	JobFailed    JobState = "failed"
+	JobCanceled  JobState = "canceled"
+)
+
+// Finished reports whether the job reached a final state
+func (s JobState) Finished() bool {
+	return s == JobSucceeded || s == JobFailed || s == JobCanceled
+}
+
+// JobInfo - Structure of an asynchronous command execution
+type JobInfo struct {
+	ID         string           `json:"id"`
+	State      JobState         `json:"state"`
+	Request    CommandRequest   `json:"request"`
+	Response   *CommandResponse `json:"response,omitempty"`
+	User       string           `json:"user,omitempty"` // Subject of the submitter when authentication is on
+	CreatedAt  time.Time        `json:"createdAt"`
+	StartedAt  *time.Time       `json:"startedAt,omitempty"`
+	FinishedAt *time.Time       `json:"finishedAt,omitempty"`
+}
+
+// BulkRequest - Structure of a command fanned out over many nodes of a type;
+// nodes are selected by exactly one of Names, Pattern (glob) or Regex
+type BulkRequest struct {
+	NodeType    string            `json:"nodeType"`
+	Names       []string          `json:"names,omitempty"`
+	Pattern     string            `json:"pattern,omitempty"`
+	Regex       string            `json:"regex,omitempty"`
+	CommandPath string            `json:"commandPath"`
+	Args        []string          `json:"args,omitempty"`
+	Flags       map[string]string `json:"flags,omitempty"`
+	Concurrency int               `json:"concurrency,omitempty"`
+}
+
+// BulkResponse - Aggregated per-node results of a bulk execution
+type BulkResponse struct {
+	Results    []CommandResult `json:"results"`
+	Total      int             `json:"total"`
+	Succeeded  int             `json:"succeeded"`
+	Failed     int             `json:"failed"`
+	DurationMs int64           `json:"durationMs"`
+	Error      string          `json:"error,omitempty"`
+}
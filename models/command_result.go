@@ -0,0 +1,52 @@
+package models
+
+// ResultStatus - Outcome of a command execution
+type ResultStatus string
+
+const (
+	StatusSuccess ResultStatus = "success" // The procedure completed
+	StatusFailure ResultStatus = "failure" // The procedure was attempted but failed
+	StatusError   ResultStatus = "error"   // The request could not be executed
+)
+
+// Error codes reported in CommandResult.ErrorCode
+const (
+	ErrCodeOperationFailed = "OPERATION_FAILED"
+	ErrCodeUnsupported     = "UNSUPPORTED"
+	ErrCodeNodeNotFound    = "NODE_NOT_FOUND"
+	ErrCodeInvalidNodeType = "INVALID_NODE_TYPE"
+	ErrCodeInvalidCommand  = "INVALID_COMMAND"
+	ErrCodeInvalidFlag     = "INVALID_FLAG"
+	ErrCodeValidation      = "VALIDATION_FAILED"
+	ErrCodeInvalidRequest  = "INVALID_REQUEST"
+	ErrCodeSessionExpired  = "SESSION_EXPIRED"
+	ErrCodeUnauthorized    = "UNAUTHORIZED"
+	ErrCodeForbidden       = "FORBIDDEN"
+	ErrCodeTimeout         = "TIMEOUT"
+	ErrCodeCanceled        = "CANCELED"
+)
+
+// CommandResult - Structured result of a command execution
+type CommandResult struct {
+	Status      ResultStatus      `json:"status"`
+	ErrorCode   string            `json:"errorCode,omitempty"`
+	NodeType    string            `json:"nodeType"`
+	NodeName    string            `json:"nodeName,omitempty"`
+	CommandPath string            `json:"commandPath"`
+	Message     string            `json:"message"`
+	Data        map[string]string `json:"data,omitempty"`
+	Table       *Table            `json:"table,omitempty"`
+	Errors      []FieldError      `json:"errors,omitempty"`
+	DurationMs  int64             `json:"durationMs"`
+}
+
+// Table - Tabular payload of a command result, e.g. the PDU sessions of a UE
+type Table struct {
+	Columns []string   `json:"columns"`
+	Rows    [][]string `json:"rows"`
+}
+
+// Succeeded reports whether the command completed successfully
+func (r *CommandResult) Succeeded() bool {
+	return r != nil && r.Status == StatusSuccess
+}
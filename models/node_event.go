@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// EventType - Kind of node state change
+type EventType string
+
+const (
+	EventNodeAdded    EventType = "node-added"   // A UE or gNB appeared in the emulator
+	EventNodeRemoved  EventType = "node-removed" // A UE or gNB was removed from the emulator
+	EventRegistered   EventType = "registered"   // A UE completed registration
+	EventDeregistered EventType = "deregistered" // A UE was deregistered
+	EventSessionUp    EventType = "session-up"   // A PDU session was established
+	EventSessionDown  EventType = "session-down" // A PDU session was released
+	EventUeReleased   EventType = "ue-released"  // A gNB released the context of a UE
+)
+
+// NodeEvent - Structure of a node state change streamed to clients
+type NodeEvent struct {
+	Type     EventType         `json:"type"`
+	NodeType string            `json:"nodeType"`
+	NodeName string            `json:"nodeName"`
+	Time     time.Time         `json:"time"`
+	Details  map[string]string `json:"details,omitempty"`
+}
@@ -0,0 +1,72 @@
+package models
+
+// UeStatus - Registration and connection state of a UE
+type UeStatus struct {
+	RmState      string          `json:"rmState"`                // RM-REGISTERED or RM-DEREGISTERED
+	CmState      string          `json:"cmState"`                // CM-IDLE or CM-CONNECTED
+	Guti         string          `json:"guti,omitempty"`         // 5G-GUTI assigned by the AMF
+	AllowedNssai []string        `json:"allowedNssai,omitempty"` // Allowed slices, SST[:SD]
+	Security     SecurityContext `json:"security"`
+}
+
+// SecurityContext - NAS security context of a UE
+type SecurityContext struct {
+	Active       bool   `json:"active"`
+	Ngksi        int    `json:"ngksi"`
+	CipheringAlg string `json:"cipheringAlg,omitempty"` // e.g. NEA2
+	IntegrityAlg string `json:"integrityAlg,omitempty"` // e.g. NIA2
+}
+
+// PduSession - PDU session of a UE
+type PduSession struct {
+	ID      uint8  `json:"id"`
+	Dnn     string `json:"dnn"`
+	Slice   string `json:"slice"`             // SST[:SD]
+	Type    string `json:"type"`              // IPv4, IPv6, IPv4v6, Ethernet or Unstructured
+	State   string `json:"state"`             // e.g. active, pending, released
+	Address string `json:"address,omitempty"` // Address allocated to the UE
+}
+
+// UeConfig - Provisioned configuration of a UE
+type UeConfig struct {
+	Supi            string   `json:"supi"`
+	Plmn            string   `json:"plmn"` // MCC-MNC
+	ConfiguredNssai []string `json:"configuredNssai,omitempty"`
+	DefaultDnn      string   `json:"defaultDnn,omitempty"`
+	GnbSearchList   []string `json:"gnbSearchList,omitempty"` // gNB addresses the UE camps on
+	IntegrityAlgs   []string `json:"integrityAlgs,omitempty"` // Supported algorithms in priority order
+	CipheringAlgs   []string `json:"cipheringAlgs,omitempty"`
+}
+
+// GnbUe - UE context held by a gNB
+type GnbUe struct {
+	UeID        string `json:"ueId"`
+	Supi        string `json:"supi,omitempty"`
+	RanUeNgapID int64  `json:"ranUeNgapId"`
+	AmfUeNgapID int64  `json:"amfUeNgapId"`
+	State       string `json:"state,omitempty"` // e.g. connected, releasing
+}
+
+// NgStatus - NG Setup state of a gNB and its AMF links
+type NgStatus struct {
+	State string    `json:"state"` // e.g. established, pending, failed, not-started
+	Amfs  []AmfLink `json:"amfs,omitempty"`
+}
+
+// AmfLink - NG association between a gNB and an AMF
+type AmfLink struct {
+	Name     string `json:"name"`
+	Address  string `json:"address"`
+	State    string `json:"state"` // e.g. connected, disconnected
+	Capacity int    `json:"capacity,omitempty"`
+}
+
+// GnbConfig - Provisioned configuration of a gNB
+type GnbConfig struct {
+	GnbID       string   `json:"gnbId"`
+	Plmns       []string `json:"plmns,omitempty"`  // MCC-MNC
+	Tacs        []string `json:"tacs,omitempty"`   // Supported tracking areas
+	Slices      []string `json:"slices,omitempty"` // SST[:SD]
+	NgapAddress string   `json:"ngapAddress,omitempty"`
+	GtpAddress  string   `json:"gtpAddress,omitempty"`
+}
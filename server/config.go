@@ -0,0 +1,360 @@
+package server
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/TutuanHo03/remote-control/server/handlers"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix is the prefix of the environment variables overriding the
+// configuration file, e.g. REMOTE_CONTROL_PORT
+const EnvPrefix = "REMOTE_CONTROL_"
+
+// Duration - time.Duration written as "30s" or "5m" in configuration files
+type Duration time.Duration
+
+// UnmarshalText parses a Go duration string
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// MarshalText formats the duration as a Go duration string
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// Config - Server configuration file, in YAML or TOML
+type Config struct {
+	Host            string                    `yaml:"host" toml:"host"`
+	Port            int                       `yaml:"port" toml:"port"`
+	SessionTimeout  Duration                  `yaml:"sessionTimeout" toml:"sessionTimeout"`
+	NodeSyncPeriod  Duration                  `yaml:"nodeSyncPeriod" toml:"nodeSyncPeriod"`
+	CommandTimeout  Duration                  `yaml:"commandTimeout" toml:"commandTimeout"`
+	ShutdownTimeout Duration                  `yaml:"shutdownTimeout" toml:"shutdownTimeout"`
+	JobWorkers      int                       `yaml:"jobWorkers" toml:"jobWorkers"`
+	AllowedOrigins  []string                  `yaml:"allowedOrigins" toml:"allowedOrigins"`
+	TLS             *TLSFileConfig            `yaml:"tls" toml:"tls"`
+	Auth            *AuthFileConfig           `yaml:"auth" toml:"auth"`
+	Audit           *AuditFileConfig          `yaml:"audit" toml:"audit"`
+	Emulator        EmulatorConfig            `yaml:"emulator" toml:"emulator"`
+	NodeTypes       map[string]NodeTypeConfig `yaml:"nodeTypes" toml:"nodeTypes"` // Settings by node type, e.g. ue
+}
+
+// TLSFileConfig - HTTPS settings of the configuration file, see TLSConfig
+type TLSFileConfig struct {
+	CertFile     string `yaml:"certFile" toml:"certFile"`
+	KeyFile      string `yaml:"keyFile" toml:"keyFile"`
+	ClientCAFile string `yaml:"clientCAFile" toml:"clientCAFile"`
+}
+
+// AuthFileConfig - Authentication settings of the configuration file
+type AuthFileConfig struct {
+	Tokens      []TokenConfig  `yaml:"tokens" toml:"tokens"`
+	JWT         *JWTFileConfig `yaml:"jwt" toml:"jwt"`
+	DefaultRole handlers.Role  `yaml:"defaultRole" toml:"defaultRole"` // Role of commands without a rule, operator when empty
+}
+
+// TokenConfig - Static API token and its caller
+type TokenConfig struct {
+	Token   string        `yaml:"token" toml:"token"`
+	Subject string        `yaml:"subject" toml:"subject"`
+	Role    handlers.Role `yaml:"role" toml:"role"`
+}
+
+// JWTFileConfig - JWT verification settings; Secret is an HMAC key and
+// PublicKeyFile a PEM public key or certificate, exactly one is required
+type JWTFileConfig struct {
+	Secret        string   `yaml:"secret" toml:"secret"`
+	PublicKeyFile string   `yaml:"publicKeyFile" toml:"publicKeyFile"`
+	Issuer        string   `yaml:"issuer" toml:"issuer"`
+	Audience      string   `yaml:"audience" toml:"audience"`
+	RoleClaim     string   `yaml:"roleClaim" toml:"roleClaim"`
+	Leeway        Duration `yaml:"leeway" toml:"leeway"`
+}
+
+// AuditFileConfig - Audit log settings of the configuration file, see AuditConfig
+type AuditFileConfig struct {
+	File     string `yaml:"file" toml:"file"`
+	MaxSize  int64  `yaml:"maxSize" toml:"maxSize"`
+	MaxFiles int    `yaml:"maxFiles" toml:"maxFiles"`
+}
+
+// EmulatorConfig - Emulator backend of a standalone server
+type EmulatorConfig struct {
+	Backend string   `yaml:"backend" toml:"backend"` // Only the built-in "mock" backend, the default
+	Ues     []string `yaml:"ues" toml:"ues"`         // Initial UEs of the mock emulator
+	Gnbs    []string `yaml:"gnbs" toml:"gnbs"`       // Initial gNBs of the mock emulator
+	Plmn    string   `yaml:"plmn" toml:"plmn"`
+}
+
+// NodeTypeConfig - Settings of the commands of a node type, keyed by command
+// path, e.g. "register" or "session create"
+type NodeTypeConfig struct {
+	CommandTimeouts map[string]Duration      `yaml:"commandTimeouts" toml:"commandTimeouts"`
+	Roles           map[string]handlers.Role `yaml:"roles" toml:"roles"` // Roles required when authentication is on
+}
+
+// LoadConfig reads a configuration file, YAML or TOML depending on its
+// extension, then applies the REMOTE_CONTROL_* environment variables; an
+// empty path only reads the environment
+func LoadConfig(path string) (*Config, error) {
+	config := &Config{}
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		switch ext := strings.ToLower(filepath.Ext(path)); ext {
+		case ".yaml", ".yml":
+			decoder := yaml.NewDecoder(bytes.NewReader(data))
+			decoder.KnownFields(true)
+			if err := decoder.Decode(config); err != nil {
+				return nil, fmt.Errorf("invalid configuration file %s: %v", path, err)
+			}
+		case ".toml":
+			decoder := toml.NewDecoder(bytes.NewReader(data))
+			decoder.DisallowUnknownFields()
+			if err := decoder.Decode(config); err != nil {
+				return nil, fmt.Errorf("invalid configuration file %s: %v", path, err)
+			}
+		default:
+			return nil, fmt.Errorf("unsupported configuration file extension %q, use .yaml, .yml or .toml", ext)
+		}
+	}
+
+	if err := config.applyEnv(os.LookupEnv); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// applyEnv overrides the settings given by environment variables
+func (c *Config) applyEnv(lookup func(string) (string, bool)) error {
+	str := func(name string, target *string) {
+		if value, ok := lookup(EnvPrefix + name); ok {
+			*target = value
+		}
+	}
+	integer := func(name string, target *int) error {
+		if value, ok := lookup(EnvPrefix + name); ok {
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("invalid %s%s %q: %v", EnvPrefix, name, value, err)
+			}
+			*target = n
+		}
+		return nil
+	}
+	duration := func(name string, target *Duration) error {
+		if value, ok := lookup(EnvPrefix + name); ok {
+			if err := target.UnmarshalText([]byte(value)); err != nil {
+				return fmt.Errorf("invalid %s%s %q: %v", EnvPrefix, name, value, err)
+			}
+		}
+		return nil
+	}
+
+	str("HOST", &c.Host)
+	if err := integer("PORT", &c.Port); err != nil {
+		return err
+	}
+	if err := integer("JOB_WORKERS", &c.JobWorkers); err != nil {
+		return err
+	}
+	for name, target := range map[string]*Duration{
+		"SESSION_TIMEOUT":  &c.SessionTimeout,
+		"NODE_SYNC_PERIOD": &c.NodeSyncPeriod,
+		"COMMAND_TIMEOUT":  &c.CommandTimeout,
+		"SHUTDOWN_TIMEOUT": &c.ShutdownTimeout,
+	} {
+		if err := duration(name, target); err != nil {
+			return err
+		}
+	}
+	if value, ok := lookup(EnvPrefix + "ALLOWED_ORIGINS"); ok {
+		c.AllowedOrigins = strings.Split(value, ",")
+	}
+
+	for _, name := range []string{"TLS_CERT", "TLS_KEY", "TLS_CLIENT_CA"} {
+		if _, ok := lookup(EnvPrefix + name); ok && c.TLS == nil {
+			c.TLS = &TLSFileConfig{}
+		}
+	}
+	if c.TLS != nil {
+		str("TLS_CERT", &c.TLS.CertFile)
+		str("TLS_KEY", &c.TLS.KeyFile)
+		str("TLS_CLIENT_CA", &c.TLS.ClientCAFile)
+	}
+
+	if _, ok := lookup(EnvPrefix + "JWT_SECRET"); ok {
+		if c.Auth == nil {
+			c.Auth = &AuthFileConfig{}
+		}
+		if c.Auth.JWT == nil {
+			c.Auth.JWT = &JWTFileConfig{}
+		}
+		str("JWT_SECRET", &c.Auth.JWT.Secret)
+	}
+
+	if _, ok := lookup(EnvPrefix + "AUDIT_FILE"); ok && c.Audit == nil {
+		c.Audit = &AuditFileConfig{}
+	}
+	if c.Audit != nil {
+		str("AUDIT_FILE", &c.Audit.File)
+	}
+
+	str("EMULATOR", &c.Emulator.Backend)
+	return nil
+}
+
+// ServerConfig converts the file settings into the configuration of NewServer
+func (c *Config) ServerConfig() (ServerConfig, error) {
+	config := ServerConfig{
+		Host:            c.Host,
+		SessionTimeout:  time.Duration(c.SessionTimeout),
+		NodeSyncPeriod:  time.Duration(c.NodeSyncPeriod),
+		CommandTimeout:  time.Duration(c.CommandTimeout),
+		ShutdownTimeout: time.Duration(c.ShutdownTimeout),
+		JobWorkers:      c.JobWorkers,
+		AllowedOrigins:  c.AllowedOrigins,
+		CommandTimeouts: make(map[string]time.Duration),
+	}
+	if c.Port != 0 {
+		config.Port = strconv.Itoa(c.Port)
+	}
+
+	for nodeType, settings := range c.NodeTypes {
+		for path, timeout := range settings.CommandTimeouts {
+			config.CommandTimeouts[nodeType+" "+path] = time.Duration(timeout)
+		}
+	}
+
+	if c.TLS != nil {
+		config.TLS = &TLSConfig{
+			CertFile:     c.TLS.CertFile,
+			KeyFile:      c.TLS.KeyFile,
+			ClientCAFile: c.TLS.ClientCAFile,
+		}
+	}
+
+	if c.Audit != nil {
+		config.Audit = &AuditConfig{
+			File:     c.Audit.File,
+			MaxSize:  c.Audit.MaxSize,
+			MaxFiles: c.Audit.MaxFiles,
+		}
+	}
+
+	if c.Auth != nil {
+		auth, err := c.Auth.authConfig(c.NodeTypes)
+		if err != nil {
+			return ServerConfig{}, err
+		}
+		config.Auth = auth
+	}
+
+	return config, nil
+}
+
+// authConfig builds the authentication and the access policy, the default
+// policy extended with the roles of the node type settings
+func (a *AuthFileConfig) authConfig(nodeTypes map[string]NodeTypeConfig) (*AuthConfig, error) {
+	auth := &AuthConfig{Tokens: handlers.StaticTokens{}}
+
+	for _, token := range a.Tokens {
+		if token.Token == "" || token.Subject == "" {
+			return nil, fmt.Errorf("API tokens need a token and a subject")
+		}
+		if !token.Role.Allows(handlers.RoleViewer) {
+			return nil, fmt.Errorf("invalid role %q of the API token of %s", token.Role, token.Subject)
+		}
+		auth.Tokens[token.Token] = handlers.Principal{Subject: token.Subject, Role: token.Role}
+	}
+
+	if a.JWT != nil {
+		key, err := a.JWT.key()
+		if err != nil {
+			return nil, err
+		}
+		auth.JWT = &handlers.JWTAuthenticator{
+			Key:       key,
+			Issuer:    a.JWT.Issuer,
+			Audience:  a.JWT.Audience,
+			RoleClaim: a.JWT.RoleClaim,
+			Leeway:    time.Duration(a.JWT.Leeway),
+		}
+	}
+
+	if len(auth.Tokens) == 0 && auth.JWT == nil {
+		return nil, fmt.Errorf("authentication requires API tokens or a JWT key")
+	}
+
+	policy := handlers.DefaultAccessPolicy()
+	if a.DefaultRole != "" {
+		if !a.DefaultRole.Allows(handlers.RoleViewer) {
+			return nil, fmt.Errorf("invalid default role %q", a.DefaultRole)
+		}
+		policy.Default = a.DefaultRole
+	}
+	for nodeType, settings := range nodeTypes {
+		for path, role := range settings.Roles {
+			if !role.Allows(handlers.RoleViewer) {
+				return nil, fmt.Errorf("invalid role %q for %s %s", role, nodeType, path)
+			}
+			policy.Rules[nodeType+" "+path] = role
+		}
+	}
+	auth.Policy = policy
+
+	return auth, nil
+}
+
+// key returns the JWT verification key
+func (j *JWTFileConfig) key() (any, error) {
+	switch {
+	case j.Secret != "" && j.PublicKeyFile != "":
+		return nil, fmt.Errorf("the JWT settings take either a secret or a public key file")
+	case j.Secret != "":
+		return []byte(j.Secret), nil
+	case j.PublicKeyFile == "":
+		return nil, fmt.Errorf("the JWT settings require a secret or a public key file")
+	}
+
+	data, err := os.ReadFile(j.PublicKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data found in %s", j.PublicKeyFile)
+	}
+	switch block.Type {
+	case "PUBLIC KEY":
+		return x509.ParsePKIXPublicKey(block.Bytes)
+	case "CERTIFICATE":
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return cert.PublicKey, nil
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q in %s, use a public key or a certificate", block.Type, j.PublicKeyFile)
+	}
+}
@@ -0,0 +1,295 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TutuanHo03/remote-control/server/handlers"
+)
+
+func TestLoadConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string // Name of the configuration file, none when empty
+		content string
+		env     map[string]string
+		want    *Config
+		wantErr string
+	}{
+		{
+			name: "yaml",
+			file: "server.yaml",
+			content: `host: 127.0.0.1
+port: 4100
+sessionTimeout: 10m
+commandTimeout: 1m30s
+allowedOrigins: [http://localhost:3000]
+emulator:
+  ues: [imsi-001010000000009]
+nodeTypes:
+  ue:
+    commandTimeouts:
+      register: 5s
+`,
+			want: &Config{
+				Host:           "127.0.0.1",
+				Port:           4100,
+				SessionTimeout: Duration(10 * time.Minute),
+				CommandTimeout: Duration(90 * time.Second),
+				AllowedOrigins: []string{"http://localhost:3000"},
+				Emulator:       EmulatorConfig{Ues: []string{"imsi-001010000000009"}},
+				NodeTypes: map[string]NodeTypeConfig{
+					"ue": {CommandTimeouts: map[string]Duration{"register": Duration(5 * time.Second)}},
+				},
+			},
+		},
+		{
+			name: "toml",
+			file: "server.toml",
+			content: `host = "127.0.0.1"
+port = 4100
+shutdownTimeout = "20s"
+
+[emulator]
+gnbs = ["gnb9"]
+plmn = "208-93"
+
+[audit]
+file = "audit.log"
+maxFiles = 3
+`,
+			want: &Config{
+				Host:            "127.0.0.1",
+				Port:            4100,
+				ShutdownTimeout: Duration(20 * time.Second),
+				Emulator:        EmulatorConfig{Gnbs: []string{"gnb9"}, Plmn: "208-93"},
+				Audit:           &AuditFileConfig{File: "audit.log", MaxFiles: 3},
+			},
+		},
+		{
+			name:    "environment over the file",
+			file:    "server.yml",
+			content: "host: 127.0.0.1\nport: 4100\n",
+			env:     map[string]string{"REMOTE_CONTROL_PORT": "4200", "REMOTE_CONTROL_NODE_SYNC_PERIOD": "2s"},
+			want:    &Config{Host: "127.0.0.1", Port: 4200, NodeSyncPeriod: Duration(2 * time.Second)},
+		},
+		{
+			name: "environment only",
+			env:  map[string]string{"REMOTE_CONTROL_HOST": "::1", "REMOTE_CONTROL_EMULATOR": "mock"},
+			want: &Config{Host: "::1", Emulator: EmulatorConfig{Backend: "mock"}},
+		},
+		{
+			name:    "unknown yaml field",
+			file:    "server.yaml",
+			content: "hots: 127.0.0.1\n",
+			wantErr: "field hots not found",
+		},
+		{
+			name:    "unknown toml field",
+			file:    "server.toml",
+			content: "hots = \"127.0.0.1\"\n",
+			wantErr: "invalid configuration file",
+		},
+		{
+			name:    "bad yaml duration",
+			file:    "server.yaml",
+			content: "sessionTimeout: 10 minutes\n",
+			wantErr: `unknown unit " minutes"`,
+		},
+		{
+			name:    "bad toml duration",
+			file:    "server.toml",
+			content: "commandTimeout = \"30\"\n",
+			wantErr: `missing unit in duration "30"`,
+		},
+		{
+			name:    "unsupported extension",
+			file:    "server.json",
+			content: "{}",
+			wantErr: `unsupported configuration file extension ".json"`,
+		},
+		{
+			name:    "bad environment value",
+			env:     map[string]string{"REMOTE_CONTROL_PORT": "http"},
+			wantErr: `invalid REMOTE_CONTROL_PORT "http"`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			for name, value := range tc.env {
+				t.Setenv(name, value)
+			}
+			path := ""
+			if tc.file != "" {
+				path = filepath.Join(t.TempDir(), tc.file)
+				if err := os.WriteFile(path, []byte(tc.content), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			config, err := LoadConfig(path)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("got error %v, want one containing %q", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(config, tc.want) {
+				t.Errorf("got %+v, want %+v", config, tc.want)
+			}
+		})
+	}
+}
+
+func TestConfigApplyEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		env     map[string]string
+		want    Config
+		wantErr string
+	}{
+		{
+			name: "scalars and durations",
+			env: map[string]string{
+				"REMOTE_CONTROL_HOST":             "0.0.0.0",
+				"REMOTE_CONTROL_PORT":             "4000",
+				"REMOTE_CONTROL_JOB_WORKERS":      "8",
+				"REMOTE_CONTROL_SESSION_TIMEOUT":  "1h",
+				"REMOTE_CONTROL_COMMAND_TIMEOUT":  "45s",
+				"REMOTE_CONTROL_SHUTDOWN_TIMEOUT": "5s",
+				"REMOTE_CONTROL_ALLOWED_ORIGINS":  "https://a.example,https://b.example",
+			},
+			want: Config{
+				Host:            "0.0.0.0",
+				Port:            4000,
+				JobWorkers:      8,
+				SessionTimeout:  Duration(time.Hour),
+				CommandTimeout:  Duration(45 * time.Second),
+				ShutdownTimeout: Duration(5 * time.Second),
+				AllowedOrigins:  []string{"https://a.example", "https://b.example"},
+			},
+		},
+		{
+			name:   "unset variables keep the file settings",
+			config: Config{Host: "127.0.0.1", Port: 4100, SessionTimeout: Duration(time.Minute)},
+			want:   Config{Host: "127.0.0.1", Port: 4100, SessionTimeout: Duration(time.Minute)},
+		},
+		{
+			name: "sections created by their variables",
+			env: map[string]string{
+				"REMOTE_CONTROL_TLS_CERT":   "server.pem",
+				"REMOTE_CONTROL_JWT_SECRET": "secret",
+				"REMOTE_CONTROL_AUDIT_FILE": "audit.log",
+			},
+			want: Config{
+				TLS:   &TLSFileConfig{CertFile: "server.pem"},
+				Auth:  &AuthFileConfig{JWT: &JWTFileConfig{Secret: "secret"}},
+				Audit: &AuditFileConfig{File: "audit.log"},
+			},
+		},
+		{
+			name:   "sections of the file completed",
+			config: Config{TLS: &TLSFileConfig{CertFile: "server.pem", KeyFile: "old-key.pem"}, Audit: &AuditFileConfig{File: "old.log", MaxFiles: 3}},
+			env:    map[string]string{"REMOTE_CONTROL_TLS_KEY": "key.pem", "REMOTE_CONTROL_AUDIT_FILE": "audit.log"},
+			want:   Config{TLS: &TLSFileConfig{CertFile: "server.pem", KeyFile: "key.pem"}, Audit: &AuditFileConfig{File: "audit.log", MaxFiles: 3}},
+		},
+		{
+			name:    "bad integer",
+			env:     map[string]string{"REMOTE_CONTROL_JOB_WORKERS": "many"},
+			wantErr: `invalid REMOTE_CONTROL_JOB_WORKERS "many"`,
+		},
+		{
+			name:    "bad duration",
+			env:     map[string]string{"REMOTE_CONTROL_COMMAND_TIMEOUT": "30"},
+			wantErr: `invalid REMOTE_CONTROL_COMMAND_TIMEOUT "30"`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			config := tc.config
+			err := config.applyEnv(func(name string) (string, bool) {
+				value, ok := tc.env[name]
+				return value, ok
+			})
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("got error %v, want one containing %q", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(config, tc.want) {
+				t.Errorf("got %+v, want %+v", config, tc.want)
+			}
+		})
+	}
+}
+
+func TestConfigAuthRoles(t *testing.T) {
+	tokens := []TokenConfig{{Token: "secret", Subject: "alice", Role: handlers.RoleOperator}}
+
+	tests := []struct {
+		name        string
+		auth        AuthFileConfig
+		nodeTypes   map[string]NodeTypeConfig
+		wantDefault handlers.Role
+		wantErr     string
+	}{
+		{
+			name:        "operator when empty",
+			auth:        AuthFileConfig{Tokens: tokens},
+			wantDefault: handlers.RoleOperator,
+		},
+		{
+			name:        "default role",
+			auth:        AuthFileConfig{Tokens: tokens, DefaultRole: handlers.RoleAdmin},
+			wantDefault: handlers.RoleAdmin,
+		},
+		{
+			name:    "unknown default role",
+			auth:    AuthFileConfig{Tokens: tokens, DefaultRole: "oprator"},
+			wantErr: `invalid default role "oprator"`,
+		},
+		{
+			name:    "unknown token role",
+			auth:    AuthFileConfig{Tokens: []TokenConfig{{Token: "secret", Subject: "alice", Role: "root"}}},
+			wantErr: `invalid role "root" of the API token of alice`,
+		},
+		{
+			name:      "unknown node type role",
+			auth:      AuthFileConfig{Tokens: tokens},
+			nodeTypes: map[string]NodeTypeConfig{"ue": {Roles: map[string]handlers.Role{"register": "oper"}}},
+			wantErr:   `invalid role "oper" for ue register`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			auth, err := tc.auth.authConfig(tc.nodeTypes)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("got error %v, want one containing %q", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if auth.Policy.Default != tc.wantDefault {
+				t.Errorf("got default role %q, want %q", auth.Policy.Default, tc.wantDefault)
+			}
+		})
+	}
+}
@@ -0,0 +1,413 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/TutuanHo03/remote-control/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// DefaultAuditMaxSize is the size at which audit files are rotated
+	DefaultAuditMaxSize = 10 << 20
+	// DefaultAuditMaxFiles is the number of rotated audit files kept
+	DefaultAuditMaxFiles = 5
+	// defaultAuditLimit is the number of records GET /api/audit returns by default
+	defaultAuditLimit = 100
+
+	// auditSourceKey and clientIPKey are the context keys of the API call and
+	// client address recorded with a command
+	auditSourceKey = "auditSource"
+	clientIPKey    = "clientIP"
+	// sessionIDKey is the gin key of the session created by a connect call
+	sessionIDKey = "sessionID"
+)
+
+// ErrAuditQueryUnsupported is returned when the audit sink cannot read its records back
+var ErrAuditQueryUnsupported = errors.New("the audit sink does not support queries")
+
+// AuditSink - Destination of audit records, e.g. a file or a log collector
+type AuditSink interface {
+	Write(record models.AuditRecord) error
+}
+
+// AuditQuerier is implemented by sinks that read their records back, for
+// GET /api/audit
+type AuditQuerier interface {
+	Query(filter AuditFilter) ([]models.AuditRecord, error)
+}
+
+// AuditFilter - Selection of audit records; empty fields match any record
+type AuditFilter struct {
+	NodeType string
+	NodeName string
+	User     string
+	Since    time.Time
+	Until    time.Time
+	Limit    int // Most recent records returned, all when 0
+}
+
+// Match reports whether a record is selected by the filter
+func (f AuditFilter) Match(record models.AuditRecord) bool {
+	switch {
+	case f.NodeType != "" && record.NodeType != f.NodeType:
+		return false
+	case f.NodeName != "" && record.NodeName != f.NodeName:
+		return false
+	case f.User != "" && record.User != f.User:
+		return false
+	case !f.Since.IsZero() && record.Time.Before(f.Since):
+		return false
+	case !f.Until.IsZero() && record.Time.After(f.Until):
+		return false
+	}
+	return true
+}
+
+// AuditLog - Records the executed commands and navigation calls in a sink
+type AuditLog struct {
+	sink AuditSink
+}
+
+// NewAuditLog creates an audit log writing to sink
+func NewAuditLog(sink AuditSink) *AuditLog {
+	return &AuditLog{sink: sink}
+}
+
+// Record writes a record to the sink; failures are logged since the audited
+// call already happened
+func (a *AuditLog) Record(record models.AuditRecord) {
+	if record.Time.IsZero() {
+		record.Time = time.Now()
+	}
+	if err := a.sink.Write(record); err != nil {
+		log.Printf("Failed to write audit record: %v", err)
+	}
+}
+
+// Query returns the records selected by filter
+func (a *AuditLog) Query(filter AuditFilter) ([]models.AuditRecord, error) {
+	querier, ok := a.sink.(AuditQuerier)
+	if !ok {
+		return nil, ErrAuditQueryUnsupported
+	}
+	return querier.Query(filter)
+}
+
+// Close closes the sink if it holds resources
+func (a *AuditLog) Close() error {
+	if closer, ok := a.sink.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// ListAudit handles GET /api/audit, filtered by the nodeType, nodeName, user,
+// since and until (RFC 3339) and limit query parameters
+func (a *AuditLog) ListAudit(c *gin.Context) {
+	filter := AuditFilter{
+		NodeType: c.Query("nodeType"),
+		NodeName: c.Query("nodeName"),
+		User:     c.Query("user"),
+		Limit:    defaultAuditLimit,
+	}
+
+	for name, t := range map[string]*time.Time{"since": &filter.Since, "until": &filter.Until} {
+		if value := c.Query(name); value != "" {
+			parsed, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid %s time %q, use RFC 3339", name, value)})
+				return
+			}
+			*t = parsed
+		}
+	}
+	if value := c.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid limit %q", value)})
+			return
+		}
+		filter.Limit = limit
+	}
+
+	records, err := a.Query(filter)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, ErrAuditQueryUnsupported) {
+			status = http.StatusNotImplemented
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"records": records,
+		"count":   len(records),
+	})
+}
+
+// FileAuditSink - AuditSink appending JSON lines to a file, rotated to
+// path.1, path.2, ... once it reaches its maximum size
+type FileAuditSink struct {
+	mu       sync.Mutex
+	path     string
+	maxSize  int64
+	maxFiles int
+	file     *os.File
+	size     int64
+}
+
+// NewFileAuditSink creates a sink writing to path; maxSize and maxFiles
+// default to DefaultAuditMaxSize and DefaultAuditMaxFiles when 0. The file
+// is opened on the first record.
+func NewFileAuditSink(path string, maxSize int64, maxFiles int) *FileAuditSink {
+	if maxSize <= 0 {
+		maxSize = DefaultAuditMaxSize
+	}
+	if maxFiles <= 0 {
+		maxFiles = DefaultAuditMaxFiles
+	}
+	return &FileAuditSink{path: path, maxSize: maxSize, maxFiles: maxFiles}
+}
+
+// Write appends a record to the file, rotating it first when it is full
+func (s *FileAuditSink) Write(record models.AuditRecord) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil {
+		if err := s.openLocked(); err != nil {
+			return err
+		}
+	}
+	if s.size > 0 && s.size+int64(len(line)) > s.maxSize {
+		if err := s.rotateLocked(); err != nil {
+			return err
+		}
+	}
+
+	n, err := s.file.Write(line)
+	s.size += int64(n)
+	return err
+}
+
+// openLocked opens the current file for appending
+func (s *FileAuditSink) openLocked() error {
+	file, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	s.file = file
+	s.size = info.Size()
+	return nil
+}
+
+// rotateLocked shifts the rotated files by one, dropping the oldest, and
+// starts a new current file
+func (s *FileAuditSink) rotateLocked() error {
+	if err := s.file.Close(); err != nil {
+		return err
+	}
+	s.file = nil
+
+	os.Remove(s.rotatedPath(s.maxFiles))
+	for i := s.maxFiles - 1; i >= 1; i-- {
+		if err := os.Rename(s.rotatedPath(i), s.rotatedPath(i+1)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	if err := os.Rename(s.path, s.rotatedPath(1)); err != nil {
+		return err
+	}
+	return s.openLocked()
+}
+
+// rotatedPath returns the path of the n-th rotated file, 1 being the newest
+func (s *FileAuditSink) rotatedPath(n int) string {
+	return fmt.Sprintf("%s.%d", s.path, n)
+}
+
+// Query reads the records selected by filter from the rotated and current
+// files, oldest first. The lock is only held to open the files: an open file
+// keeps its records when a rotation renames it, so writes do not wait for the
+// scan.
+func (s *FileAuditSink) Query(filter AuditFilter) ([]models.AuditRecord, error) {
+	files, err := s.openFiles()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		for _, file := range files {
+			file.Close()
+		}
+	}()
+
+	var records []models.AuditRecord
+	for _, file := range files {
+		scanner := bufio.NewScanner(file)
+		scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+		for scanner.Scan() {
+			var record models.AuditRecord
+			if json.Unmarshal(scanner.Bytes(), &record) != nil {
+				continue
+			}
+			if filter.Match(record) {
+				records = append(records, record)
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("failed to read %s: %v", file.Name(), err)
+		}
+	}
+
+	if filter.Limit > 0 && len(records) > filter.Limit {
+		records = records[len(records)-filter.Limit:]
+	}
+	return records, nil
+}
+
+// openFiles opens the existing rotated and current files, oldest first
+func (s *FileAuditSink) openFiles() ([]*os.File, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	paths := []string{}
+	for i := s.maxFiles; i >= 1; i-- {
+		paths = append(paths, s.rotatedPath(i))
+	}
+	paths = append(paths, s.path)
+
+	var files []*os.File
+	for _, path := range paths {
+		file, err := os.Open(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			for _, opened := range files {
+				opened.Close()
+			}
+			return nil, err
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+// Close closes the current file
+func (s *FileAuditSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
+
+// SetAuditLog records every command execution in an audit log, nil disables
+// auditing
+func (s *CommandStore) SetAuditLog(audit *AuditLog) {
+	s.auditMu.Lock()
+	defer s.auditMu.Unlock()
+	s.audit = audit
+}
+
+// auditLog returns the audit log, nil when auditing is off
+func (s *CommandStore) auditLog() *AuditLog {
+	s.auditMu.RLock()
+	defer s.auditMu.RUnlock()
+	return s.audit
+}
+
+// withAuditSource marks the API call a context executes commands for
+func withAuditSource(ctx context.Context, source models.AuditSource) context.Context {
+	return context.WithValue(ctx, auditSourceKey, source)
+}
+
+// withClientIP stores the address of the caller in a context
+func withClientIP(ctx context.Context, clientIP string) context.Context {
+	return context.WithValue(ctx, clientIPKey, clientIP)
+}
+
+// newAuditRecord fills in the caller details of a record from a context
+func newAuditRecord(ctx context.Context, source models.AuditSource, start time.Time) models.AuditRecord {
+	record := models.AuditRecord{
+		Time:       start,
+		Source:     source,
+		DurationMs: time.Since(start).Milliseconds(),
+	}
+	if s, ok := ctx.Value(auditSourceKey).(models.AuditSource); ok {
+		record.Source = s
+	}
+	if principal, ok := GetPrincipal(ctx); ok {
+		record.User = principal.Subject
+		record.Role = string(principal.Role)
+	}
+	record.ClientIP, _ = ctx.Value(clientIPKey).(string)
+	return record
+}
+
+// auditCommand records the outcome of a command execution
+func (s *CommandStore) auditCommand(ctx context.Context, req models.CommandRequest, start time.Time, response models.CommandResponse, err error) {
+	audit := s.auditLog()
+	if audit == nil {
+		return
+	}
+
+	record := newAuditRecord(ctx, models.AuditExec, start)
+	record.SessionID = req.SessionID
+	record.NodeType = req.NodeType
+	record.NodeName = req.NodeName
+	record.CommandPath = req.CommandPath
+	record.Args = req.Args
+	record.Flags = req.Flags
+
+	// Raw command lines are recorded as parsed when they are valid
+	if line, parseErr := s.parseCommandLine(req); parseErr == nil {
+		record.CommandPath = line.Path
+		record.Args = line.Args
+		record.Flags = line.Flags
+	} else if record.CommandPath == "" {
+		record.CommandPath = req.RawCommand
+	}
+
+	if err != nil {
+		_, response = commandErrorResponse(req, err)
+	}
+	if result := response.Result; result != nil {
+		record.Outcome = result.Status
+		record.ErrorCode = result.ErrorCode
+		if !result.Succeeded() {
+			record.Message = result.Message
+		}
+	}
+
+	audit.Record(record)
+}
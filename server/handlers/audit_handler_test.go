@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/TutuanHo03/remote-control/models"
+)
+
+func TestFileAuditSinkRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	record := func(i int) models.AuditRecord {
+		return models.AuditRecord{Time: time.Unix(int64(i), 0).UTC(), NodeType: "ue", NodeName: fmt.Sprintf("node%d", i)}
+	}
+	line, err := json.Marshal(record(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Two records per file, the current one and two rotated ones kept
+	sink := NewFileAuditSink(path, int64(2*(len(line)+1)), 2)
+	defer sink.Close()
+	for i := range 10 {
+		if err := sink.Write(record(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, name := range []string{path, path + ".1", path + ".2"} {
+		if _, err := os.Stat(name); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("%s.3 kept beyond the maximum number of files", path)
+	}
+
+	records, err := sink.Query(AuditFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, r := range records {
+		names = append(names, r.NodeName)
+	}
+	if want := []string{"node4", "node5", "node6", "node7", "node8", "node9"}; !slices.Equal(names, want) {
+		t.Errorf("got %v, want %v", names, want)
+	}
+}
+
+func TestFileAuditSinkQuery(t *testing.T) {
+	sink := NewFileAuditSink(filepath.Join(t.TempDir(), "audit.log"), 0, 0)
+	defer sink.Close()
+
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	records := []models.AuditRecord{
+		{NodeType: "emulator", NodeName: "emulator", User: "alice"},
+		{NodeType: "ue", NodeName: "imsi-001010000000001", User: "alice"},
+		{NodeType: "ue", NodeName: "imsi-001010000000002", User: "bob"},
+		{NodeType: "gnb", NodeName: "gnb1", User: "bob"},
+		{NodeType: "ue", NodeName: "imsi-001010000000001", User: "bob"},
+	}
+	for i := range records {
+		records[i].Time = start.Add(time.Duration(i) * time.Minute)
+		records[i].CommandPath = fmt.Sprintf("command%d", i)
+		if err := sink.Write(records[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		filter AuditFilter
+		want   []string
+	}{
+		{"all", AuditFilter{}, []string{"command0", "command1", "command2", "command3", "command4"}},
+		{"node type", AuditFilter{NodeType: "ue"}, []string{"command1", "command2", "command4"}},
+		{"node name", AuditFilter{NodeName: "imsi-001010000000001"}, []string{"command1", "command4"}},
+		{"user", AuditFilter{User: "alice"}, []string{"command0", "command1"}},
+		{"since", AuditFilter{Since: start.Add(3 * time.Minute)}, []string{"command3", "command4"}},
+		{"until", AuditFilter{Until: start.Add(time.Minute)}, []string{"command0", "command1"}},
+		{"range", AuditFilter{Since: start.Add(time.Minute), Until: start.Add(3 * time.Minute)}, []string{"command1", "command2", "command3"}},
+		{"limit keeps the most recent", AuditFilter{Limit: 2}, []string{"command3", "command4"}},
+		{"combined", AuditFilter{NodeType: "ue", User: "bob", Limit: 1}, []string{"command4"}},
+		{"no match", AuditFilter{User: "carol"}, nil},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := sink.Query(tc.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var paths []string
+			for _, r := range got {
+				paths = append(paths, r.CommandPath)
+			}
+			if !slices.Equal(paths, tc.want) {
+				t.Errorf("got %v, want %v", paths, tc.want)
+			}
+		})
+	}
+}
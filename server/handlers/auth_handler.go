@@ -0,0 +1,325 @@
+package handlers
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/TutuanHo03/remote-control/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	// ErrNoCredentials is returned for requests without credentials
+	ErrNoCredentials = errors.New("no credentials")
+	// ErrInvalidCredentials is returned for unknown tokens or invalid JWTs
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	// ErrForbidden is returned when the role of the caller does not allow the command
+	ErrForbidden = errors.New("forbidden")
+)
+
+// PrincipalKey is the context key of the authenticated caller
+const PrincipalKey = "principal"
+
+// Role - Access level of an authenticated caller, each role includes the
+// rights of the roles below it
+type Role string
+
+const (
+	RoleViewer   Role = "viewer"   // Runs read-only commands
+	RoleOperator Role = "operator" // Also runs procedures on the nodes
+	RoleAdmin    Role = "admin"    // Also provisions nodes and sees every session
+)
+
+// rank orders the roles, unknown roles have no rights
+func (r Role) rank() int {
+	switch r {
+	case RoleViewer:
+		return 1
+	case RoleOperator:
+		return 2
+	case RoleAdmin:
+		return 3
+	default:
+		return 0
+	}
+}
+
+// Allows reports whether the role includes the rights of another role; an
+// unknown or empty required role allows no one
+func (r Role) Allows(required Role) bool {
+	return r.rank() > 0 && required.rank() > 0 && r.rank() >= required.rank()
+}
+
+// Principal - Authenticated caller of the API
+type Principal struct {
+	Subject string `json:"subject"`
+	Role    Role   `json:"role"`
+}
+
+// GetPrincipal returns the authenticated caller of a request
+func GetPrincipal(ctx context.Context) (Principal, bool) {
+	principal, ok := ctx.Value(PrincipalKey).(Principal)
+	return principal, ok
+}
+
+// Authenticator verifies the credentials of a request and returns its caller,
+// an error wrapping ErrNoCredentials or ErrInvalidCredentials otherwise
+type Authenticator interface {
+	Authenticate(r *http.Request) (Principal, error)
+}
+
+// bearerToken returns the token of the Authorization or X-API-Key header
+func bearerToken(r *http.Request) string {
+	if scheme, token, found := strings.Cut(r.Header.Get("Authorization"), " "); found && strings.EqualFold(scheme, "Bearer") {
+		return strings.TrimSpace(token)
+	}
+	return r.Header.Get("X-API-Key")
+}
+
+// StaticTokens - Authenticator of long-lived API tokens, mapped to their caller
+type StaticTokens map[string]Principal
+
+// Authenticate looks the bearer token up in the token table
+func (t StaticTokens) Authenticate(r *http.Request) (Principal, error) {
+	token := bearerToken(r)
+	if token == "" {
+		return Principal{}, ErrNoCredentials
+	}
+
+	for known, principal := range t {
+		if subtle.ConstantTimeCompare([]byte(known), []byte(token)) == 1 {
+			return principal, nil
+		}
+	}
+	return Principal{}, fmt.Errorf("%w: unknown API token", ErrInvalidCredentials)
+}
+
+// JWTAuthenticator - Authenticator of signed JWTs; the subject names the
+// caller and the role claim holds its role, or a list of roles. Tokens must
+// expire, those without an exp claim are rejected.
+type JWTAuthenticator struct {
+	Key       any    // []byte for HMAC, or an RSA, ECDSA or Ed25519 public key
+	Issuer    string // Expected iss claim, not checked when empty
+	Audience  string // Expected aud claim, not checked when empty
+	RoleClaim string // Claim holding the role, "role" by default
+	Leeway    time.Duration
+}
+
+// Authenticate verifies the signature and claims of the bearer token
+func (a *JWTAuthenticator) Authenticate(r *http.Request) (Principal, error) {
+	token := bearerToken(r)
+	if token == "" {
+		return Principal{}, ErrNoCredentials
+	}
+
+	var methods []string
+	switch a.Key.(type) {
+	case []byte:
+		methods = []string{"HS256", "HS384", "HS512"}
+	case *rsa.PublicKey:
+		methods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512"}
+	case *ecdsa.PublicKey:
+		methods = []string{"ES256", "ES384", "ES512"}
+	case ed25519.PublicKey:
+		methods = []string{"EdDSA"}
+	default:
+		return Principal{}, fmt.Errorf("%w: unsupported JWT key type %T", ErrInvalidCredentials, a.Key)
+	}
+
+	options := []jwt.ParserOption{jwt.WithValidMethods(methods), jwt.WithLeeway(a.Leeway), jwt.WithExpirationRequired()}
+	if a.Issuer != "" {
+		options = append(options, jwt.WithIssuer(a.Issuer))
+	}
+	if a.Audience != "" {
+		options = append(options, jwt.WithAudience(a.Audience))
+	}
+
+	claims := jwt.MapClaims{}
+	if _, err := jwt.ParseWithClaims(token, claims, func(*jwt.Token) (any, error) { return a.Key, nil }, options...); err != nil {
+		return Principal{}, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+	}
+
+	subject, _ := claims.GetSubject()
+	roleClaim := a.RoleClaim
+	if roleClaim == "" {
+		roleClaim = "role"
+	}
+
+	// Callers with several roles act with the highest one
+	var role Role
+	switch value := claims[roleClaim].(type) {
+	case string:
+		role = Role(value)
+	case []any:
+		for _, v := range value {
+			if r, ok := v.(string); ok && Role(r).rank() > role.rank() {
+				role = Role(r)
+			}
+		}
+	}
+	if role.rank() == 0 {
+		return Principal{}, fmt.Errorf("%w: no valid %s claim", ErrInvalidCredentials, roleClaim)
+	}
+
+	return Principal{Subject: subject, Role: role}, nil
+}
+
+// Authenticators - Authenticator trying several authenticators in order, e.g.
+// static tokens for scripts and JWTs for operators
+type Authenticators []Authenticator
+
+// Authenticate returns the caller found by the first authenticator accepting
+// the credentials
+func (a Authenticators) Authenticate(r *http.Request) (Principal, error) {
+	err := ErrNoCredentials
+	for _, auth := range a {
+		principal, authErr := auth.Authenticate(r)
+		if authErr == nil {
+			return principal, nil
+		}
+		if !errors.Is(authErr, ErrNoCredentials) {
+			err = authErr
+		}
+	}
+	return Principal{}, err
+}
+
+// AccessPolicy - Roles required to run commands. Rules are keyed by
+// "<node-type> <command-path>" and may use path.Match patterns, e.g.
+// "emulator *"; the longest matching rule applies.
+type AccessPolicy struct {
+	Rules   map[string]Role `json:"rules"`
+	Default Role            `json:"default"` // Role of commands without a rule, operator when empty
+}
+
+// DefaultAccessPolicy lets viewers run the queries of the built-in node types,
+// operators run procedures and admins provision nodes
+func DefaultAccessPolicy() *AccessPolicy {
+	return &AccessPolicy{
+		Rules: map[string]Role{
+			"emulator list-ue":  RoleViewer,
+			"emulator list-gnb": RoleViewer,
+			"emulator *":        RoleAdmin,
+			"ue status":         RoleViewer,
+			"ue list-sessions":  RoleViewer,
+			"ue session list":   RoleViewer,
+			"ue show-config":    RoleViewer,
+			"gnb list-ues":      RoleViewer,
+			"gnb ng-status":     RoleViewer,
+			"gnb show-config":   RoleViewer,
+		},
+		Default: RoleOperator,
+	}
+}
+
+// RequiredRole returns the role needed to run a command
+func (p *AccessPolicy) RequiredRole(nodeType, commandPath string) Role {
+	key := nodeType + " " + commandPath
+	if role, ok := p.Rules[key]; ok {
+		return role
+	}
+
+	var best string
+	for pattern := range p.Rules {
+		if matched, _ := path.Match(pattern, key); matched && len(pattern) > len(best) {
+			best = pattern
+		}
+	}
+	if best != "" {
+		return p.Rules[best]
+	}
+
+	if p.Default == "" {
+		return RoleOperator
+	}
+	return p.Default
+}
+
+// SetAccessPolicy makes command executions check the role of their caller,
+// nil runs every command without checks
+func (s *CommandStore) SetAccessPolicy(policy *AccessPolicy) {
+	s.policyMu.Lock()
+	defer s.policyMu.Unlock()
+	s.policy = policy
+}
+
+// authorize checks that the caller of a context may run a command
+func (s *CommandStore) authorize(ctx context.Context, nodeType, commandPath string) error {
+	s.policyMu.RLock()
+	policy := s.policy
+	s.policyMu.RUnlock()
+
+	if policy == nil {
+		return nil
+	}
+
+	principal, ok := GetPrincipal(ctx)
+	if !ok {
+		return ErrNoCredentials
+	}
+	if required := policy.RequiredRole(nodeType, commandPath); !principal.Role.Allows(required) {
+		return fmt.Errorf("%w: %s %s requires the %s role, %s is %s", ErrForbidden, nodeType, commandPath, required, principal.Subject, principal.Role)
+	}
+	return nil
+}
+
+// Authorize checks that the caller of a context may run a command request,
+// before it is queued or fanned out
+func (s *CommandStore) Authorize(ctx context.Context, req models.CommandRequest) error {
+	s.policyMu.RLock()
+	checked := s.policy != nil
+	s.policyMu.RUnlock()
+	if !checked {
+		return nil
+	}
+
+	line, err := s.parseCommandLine(req)
+	if err != nil {
+		return err
+	}
+	return s.authorize(ctx, req.NodeType, line.Path)
+}
+
+// AuthMiddleware authenticates every request and stores its caller in the
+// request context; requests without valid credentials get HTTP 401
+func AuthMiddleware(auth Authenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, err := auth.Authenticate(c.Request)
+		if err != nil {
+			c.Header("WWW-Authenticate", `Bearer realm="remote-control"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error": "authentication required: " + err.Error(),
+			})
+			return
+		}
+
+		c.Set(PrincipalKey, principal)
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), PrincipalKey, principal))
+		c.Next()
+	}
+}
+
+// RequireRole rejects requests of callers below a role with HTTP 403
+func RequireRole(role Role) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, _ := GetPrincipal(c.Request.Context())
+		if !principal.Role.Allows(role) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error": fmt.Sprintf("the %s role is required", role),
+			})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,285 @@
+package handlers
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/TutuanHo03/remote-control/models"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestJWTAuthenticate(t *testing.T) {
+	secret := []byte("test-secret")
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+
+	hmacToken := func(claims jwt.MapClaims) string {
+		signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(secret)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signed
+	}
+	ecToken := func(claims jwt.MapClaims) string {
+		signed, err := jwt.NewWithClaims(jwt.SigningMethodES256, claims).SignedString(ecKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signed
+	}
+	valid := func() jwt.MapClaims {
+		return jwt.MapClaims{"sub": "alice", "role": "operator", "exp": now.Add(time.Hour).Unix()}
+	}
+	with := func(claims jwt.MapClaims, name string, value any) jwt.MapClaims {
+		claims[name] = value
+		return claims
+	}
+
+	tests := []struct {
+		name  string
+		auth  *JWTAuthenticator
+		token string
+		want  Principal
+		err   error
+	}{
+		{
+			name:  "hmac",
+			auth:  &JWTAuthenticator{Key: secret},
+			token: hmacToken(valid()),
+			want:  Principal{Subject: "alice", Role: RoleOperator},
+		},
+		{
+			name:  "ecdsa",
+			auth:  &JWTAuthenticator{Key: &ecKey.PublicKey},
+			token: ecToken(valid()),
+			want:  Principal{Subject: "alice", Role: RoleOperator},
+		},
+		{
+			name:  "highest of several roles",
+			auth:  &JWTAuthenticator{Key: secret},
+			token: hmacToken(with(valid(), "role", []any{"viewer", "admin", "unknown"})),
+			want:  Principal{Subject: "alice", Role: RoleAdmin},
+		},
+		{
+			name:  "custom role claim",
+			auth:  &JWTAuthenticator{Key: secret, RoleClaim: "rc_role"},
+			token: hmacToken(with(valid(), "rc_role", "viewer")),
+			want:  Principal{Subject: "alice", Role: RoleViewer},
+		},
+		{
+			name:  "issuer and audience",
+			auth:  &JWTAuthenticator{Key: secret, Issuer: "idp", Audience: "remote-control"},
+			token: hmacToken(with(with(valid(), "iss", "idp"), "aud", "remote-control")),
+			want:  Principal{Subject: "alice", Role: RoleOperator},
+		},
+		{
+			name:  "expired within the leeway",
+			auth:  &JWTAuthenticator{Key: secret, Leeway: time.Minute},
+			token: hmacToken(with(valid(), "exp", now.Add(-30*time.Second).Unix())),
+			want:  Principal{Subject: "alice", Role: RoleOperator},
+		},
+		{
+			name:  "expired",
+			auth:  &JWTAuthenticator{Key: secret},
+			token: hmacToken(with(valid(), "exp", now.Add(-time.Minute).Unix())),
+			err:   ErrInvalidCredentials,
+		},
+		{
+			name: "no expiry",
+			auth: &JWTAuthenticator{Key: secret},
+			token: hmacToken(func() jwt.MapClaims {
+				claims := valid()
+				delete(claims, "exp")
+				return claims
+			}()),
+			err: ErrInvalidCredentials,
+		},
+		{
+			name:  "not valid yet",
+			auth:  &JWTAuthenticator{Key: secret},
+			token: hmacToken(with(valid(), "nbf", now.Add(time.Hour).Unix())),
+			err:   ErrInvalidCredentials,
+		},
+		{
+			name:  "wrong secret",
+			auth:  &JWTAuthenticator{Key: []byte("other-secret")},
+			token: hmacToken(valid()),
+			err:   ErrInvalidCredentials,
+		},
+		{
+			name:  "algorithm not allowed for the key",
+			auth:  &JWTAuthenticator{Key: secret},
+			token: ecToken(valid()),
+			err:   ErrInvalidCredentials,
+		},
+		{
+			name: "unsigned",
+			auth: &JWTAuthenticator{Key: secret},
+			token: func() string {
+				signed, err := jwt.NewWithClaims(jwt.SigningMethodNone, valid()).SignedString(jwt.UnsafeAllowNoneSignatureType)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return signed
+			}(),
+			err: ErrInvalidCredentials,
+		},
+		{
+			name:  "wrong issuer",
+			auth:  &JWTAuthenticator{Key: secret, Issuer: "idp"},
+			token: hmacToken(with(valid(), "iss", "other")),
+			err:   ErrInvalidCredentials,
+		},
+		{
+			name:  "wrong audience",
+			auth:  &JWTAuthenticator{Key: secret, Audience: "remote-control"},
+			token: hmacToken(with(valid(), "aud", "other")),
+			err:   ErrInvalidCredentials,
+		},
+		{
+			name:  "unknown role",
+			auth:  &JWTAuthenticator{Key: secret},
+			token: hmacToken(with(valid(), "role", "root")),
+			err:   ErrInvalidCredentials,
+		},
+		{
+			name: "no token",
+			auth: &JWTAuthenticator{Key: secret},
+			err:  ErrNoCredentials,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r, _ := http.NewRequest(http.MethodGet, "/api/exec", nil)
+			if tc.token != "" {
+				r.Header.Set("Authorization", "Bearer "+tc.token)
+			}
+
+			principal, err := tc.auth.Authenticate(r)
+			if tc.err != nil {
+				if !errors.Is(err, tc.err) {
+					t.Fatalf("got error %v, want %v", err, tc.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if principal != tc.want {
+				t.Errorf("got %+v, want %+v", principal, tc.want)
+			}
+		})
+	}
+}
+
+func TestRoleAllows(t *testing.T) {
+	tests := []struct {
+		role, required Role
+		want           bool
+	}{
+		{RoleViewer, RoleViewer, true},
+		{RoleViewer, RoleOperator, false},
+		{RoleOperator, RoleViewer, true},
+		{RoleAdmin, RoleOperator, true},
+		{RoleOperator, RoleAdmin, false},
+		{"root", RoleViewer, false},
+		{"", RoleViewer, false},
+		{RoleAdmin, "oprator", false},
+		{RoleAdmin, "", false},
+	}
+	for _, tc := range tests {
+		if got := tc.role.Allows(tc.required); got != tc.want {
+			t.Errorf("%q allows %q: got %t, want %t", tc.role, tc.required, got, tc.want)
+		}
+	}
+}
+
+func TestAccessPolicyUnknownRole(t *testing.T) {
+	emu := newFakeEmulator()
+	store := NewCommandStore(emu, emu, emu)
+	store.SetAccessPolicy(&AccessPolicy{
+		Rules:   map[string]Role{"ue register": "oprator"},
+		Default: "viewr",
+	})
+
+	ctx := context.WithValue(context.Background(), PrincipalKey, Principal{Subject: "carol", Role: RoleAdmin})
+	for _, command := range []string{"register", "deregister"} {
+		req := models.CommandRequest{NodeType: "ue", NodeName: "imsi-001010000000001", RawCommand: command}
+		if _, err := store.ExecuteCommand(ctx, req); !errors.Is(err, ErrForbidden) {
+			t.Errorf("%s: got error %v, want %v", command, err, ErrForbidden)
+		}
+	}
+}
+
+func TestAccessPolicyRequiredRole(t *testing.T) {
+	policy := &AccessPolicy{
+		Rules: map[string]Role{
+			"emulator list-ue": RoleViewer,
+			"emulator *":       RoleAdmin,
+			"ue session *":     RoleViewer,
+			"ue session c*":    RoleOperator,
+			"gnb release-*":    RoleAdmin,
+		},
+		Default: RoleOperator,
+	}
+
+	tests := []struct {
+		nodeType, commandPath string
+		want                  Role
+	}{
+		{"emulator", "list-ue", RoleViewer}, // Exact rule over a pattern
+		{"emulator", "add-ue", RoleAdmin},
+		{"ue", "session list", RoleViewer},
+		{"ue", "session create", RoleOperator}, // Longest matching pattern
+		{"ue", "register", RoleOperator},       // Default
+		{"gnb", "release-ue", RoleAdmin},
+		{"gnb", "list-ues", RoleOperator},
+	}
+	for _, tc := range tests {
+		if got := policy.RequiredRole(tc.nodeType, tc.commandPath); got != tc.want {
+			t.Errorf("%s %s: got %s, want %s", tc.nodeType, tc.commandPath, got, tc.want)
+		}
+	}
+
+	if got := (&AccessPolicy{}).RequiredRole("ue", "register"); got != RoleOperator {
+		t.Errorf("empty policy: got %s, want %s", got, RoleOperator)
+	}
+}
+
+func TestHelpAuthorized(t *testing.T) {
+	emu := newFakeEmulator()
+	store := NewCommandStore(emu, emu, emu)
+	store.SetAccessPolicy(DefaultAccessPolicy())
+
+	for _, tc := range []struct {
+		role    Role
+		command string
+		err     error
+	}{
+		{RoleViewer, "list-ue --help", nil},
+		{RoleViewer, "add-ue --help", ErrForbidden},
+		{RoleOperator, "add-ue --help", ErrForbidden},
+		{RoleAdmin, "add-ue --help", nil},
+	} {
+		ctx := context.WithValue(context.Background(), PrincipalKey, Principal{Subject: "carol", Role: tc.role})
+		req := models.CommandRequest{NodeType: "emulator", NodeName: "emulator", RawCommand: tc.command}
+		response, err := store.ExecuteCommand(ctx, req)
+		if !errors.Is(err, tc.err) {
+			t.Errorf("%s as %s: got error %v, want %v", tc.command, tc.role, err, tc.err)
+		}
+		if err == nil && response.Result.Status != models.StatusSuccess {
+			t.Errorf("%s as %s: got %+v, want the help", tc.command, tc.role, response.Result)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/TutuanHo03/remote-control/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// DefaultBulkConcurrency is the number of nodes a bulk request runs on at once
+	DefaultBulkConcurrency = 16
+	// MaxBulkConcurrency caps the concurrency a bulk request may ask for
+	MaxBulkConcurrency = 256
+)
+
+// ErrInvalidSelector is returned for bulk requests without a valid node selector
+var ErrInvalidSelector = errors.New("invalid node selector")
+
+// SelectNodes returns the sorted names of the nodes matched by a bulk request
+func (s *CommandStore) SelectNodes(req models.BulkRequest) ([]string, error) {
+	selectors := 0
+	for _, set := range []bool{len(req.Names) > 0, req.Pattern != "", req.Regex != ""} {
+		if set {
+			selectors++
+		}
+	}
+	if selectors != 1 {
+		return nil, fmt.Errorf("%w: use exactly one of names, pattern or regex", ErrInvalidSelector)
+	}
+
+	// Explicit names are kept as given, unknown ones fail individually
+	if len(req.Names) > 0 {
+		names := append([]string(nil), req.Names...)
+		sort.Strings(names)
+		return names, nil
+	}
+
+	objects, err := s.GetObjectsOfType(req.NodeType)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidNodeType, err)
+	}
+
+	var match func(string) bool
+	if req.Pattern != "" {
+		if _, err := path.Match(req.Pattern, ""); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidSelector, err)
+		}
+		match = func(name string) bool {
+			matched, _ := path.Match(req.Pattern, name)
+			return matched
+		}
+	} else {
+		re, err := regexp.Compile(req.Regex)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidSelector, err)
+		}
+		match = re.MatchString
+	}
+
+	var names []string
+	for _, name := range objects {
+		if match(name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	return names, nil
+}
+
+// ExecuteBulk runs a command on every selected node with bounded concurrency
+func (s *CommandStore) ExecuteBulk(ctx context.Context, req models.BulkRequest) (models.BulkResponse, error) {
+	start := time.Now()
+
+	ctx = withAuditSource(ctx, models.AuditBulk)
+
+	// Denied commands are rejected once rather than on every node
+	cmdReq := models.CommandRequest{
+		NodeType:    req.NodeType,
+		CommandPath: req.CommandPath,
+		Args:        req.Args,
+		Flags:       req.Flags,
+	}
+	if err := s.Authorize(ctx, cmdReq); err != nil {
+		s.auditCommand(ctx, cmdReq, start, models.CommandResponse{}, err)
+		return models.BulkResponse{}, err
+	}
+
+	names, err := s.SelectNodes(req)
+	if err != nil {
+		return models.BulkResponse{}, err
+	}
+
+	concurrency := req.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultBulkConcurrency
+	}
+	if concurrency > MaxBulkConcurrency {
+		concurrency = MaxBulkConcurrency
+	}
+
+	results := make([]models.CommandResult, len(names))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+	for i, name := range names {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, name string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			cmdReq := models.CommandRequest{
+				NodeType:    req.NodeType,
+				NodeName:    name,
+				CommandPath: req.CommandPath,
+				Args:        req.Args,
+				Flags:       req.Flags,
+			}
+			itemStart := time.Now()
+			response, err := s.ExecuteCommand(ctx, cmdReq)
+			if err != nil {
+				_, response = commandErrorResponse(cmdReq, err)
+				response.Result.DurationMs = time.Since(itemStart).Milliseconds()
+			}
+			results[i] = *response.Result
+		}(i, name)
+	}
+	wg.Wait()
+
+	bulk := models.BulkResponse{
+		Results:    results,
+		Total:      len(results),
+		DurationMs: time.Since(start).Milliseconds(),
+	}
+	for _, result := range results {
+		if result.Succeeded() {
+			bulk.Succeeded++
+		} else {
+			bulk.Failed++
+		}
+	}
+
+	return bulk, nil
+}
+
+// ExecuteBulk handles bulk execution requests
+func (h *ContextHandler) ExecuteBulk(c *gin.Context) {
+	var req models.BulkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.BulkResponse{
+			Error: "Invalid request format: " + err.Error(),
+		})
+		return
+	}
+
+	ctx := withClientIP(c.Request.Context(), c.ClientIP())
+	response, err := h.commandStore.ExecuteBulk(ctx, req)
+	if err != nil {
+		status := http.StatusBadRequest
+		switch {
+		case errors.Is(err, ErrNoCredentials), errors.Is(err, ErrInvalidCredentials):
+			status = http.StatusUnauthorized
+		case errors.Is(err, ErrForbidden):
+			status = http.StatusForbidden
+		}
+		c.JSON(status, models.BulkResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
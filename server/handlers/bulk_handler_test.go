@@ -0,0 +1,244 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/TutuanHo03/remote-control/models"
+
+	"github.com/urfave/cli/v3"
+)
+
+// probeType is a node type whose probe command fails on the nodes with an odd
+// number and records how many probes run at once
+type probeType struct {
+	mu      sync.Mutex
+	running int
+	peak    int
+}
+
+func (p *probeType) spec() NodeTypeSpec {
+	return NodeTypeSpec{
+		Name: "probe",
+		ListNodes: func() []string {
+			names := make([]string, 0, 10)
+			for i := range 10 {
+				names = append(names, fmt.Sprintf("node%d", i))
+			}
+			return names
+		},
+		Resolve: func(name string) (any, error) {
+			if !strings.HasPrefix(name, "node") {
+				return nil, ErrNodeNotFound
+			}
+			return name, nil
+		},
+		Commands: func() *cli.Command {
+			return &cli.Command{
+				Name: "probe",
+				Commands: []*cli.Command{
+					{
+						Name: "probe",
+						Action: func(ctx context.Context, cmd *cli.Command) error {
+							p.mu.Lock()
+							p.running++
+							p.peak = max(p.peak, p.running)
+							p.mu.Unlock()
+
+							time.Sleep(20 * time.Millisecond)
+
+							p.mu.Lock()
+							p.running--
+							p.mu.Unlock()
+
+							name := GetNode(ctx).(string)
+							if (name[len(name)-1]-'0')%2 == 1 {
+								Fail(ctx, models.ErrCodeOperationFailed, name+" failed", nil)
+							} else {
+								Succeed(ctx, name+" probed", nil)
+							}
+							return nil
+						},
+					},
+				},
+			}
+		},
+	}
+}
+
+func TestSelectNodes(t *testing.T) {
+	emu := newFakeEmulator()
+	emu.ues = append(emu.ues, "imsi-001020000000001")
+	store := NewCommandStore(emu, emu, emu)
+
+	tests := []struct {
+		name string
+		req  models.BulkRequest
+		want []string
+		err  error
+	}{
+		{
+			name: "names sorted and kept when unknown",
+			req:  models.BulkRequest{NodeType: "ue", Names: []string{"imsi-001010000000002", "imsi-999990000000000"}},
+			want: []string{"imsi-001010000000002", "imsi-999990000000000"},
+		},
+		{
+			name: "all nodes",
+			req:  models.BulkRequest{NodeType: "ue", Pattern: "*"},
+			want: []string{"imsi-001010000000001", "imsi-001010000000002", "imsi-001020000000001"},
+		},
+		{
+			name: "glob",
+			req:  models.BulkRequest{NodeType: "ue", Pattern: "imsi-00101*"},
+			want: []string{"imsi-001010000000001", "imsi-001010000000002"},
+		},
+		{
+			name: "regex",
+			req:  models.BulkRequest{NodeType: "ue", Regex: "1$"},
+			want: []string{"imsi-001010000000001", "imsi-001020000000001"},
+		},
+		{
+			name: "no match",
+			req:  models.BulkRequest{NodeType: "gnb", Pattern: "ue*"},
+		},
+		{
+			name: "no selector",
+			req:  models.BulkRequest{NodeType: "ue"},
+			err:  ErrInvalidSelector,
+		},
+		{
+			name: "several selectors",
+			req:  models.BulkRequest{NodeType: "ue", Pattern: "*", Regex: ".*"},
+			err:  ErrInvalidSelector,
+		},
+		{
+			name: "bad glob",
+			req:  models.BulkRequest{NodeType: "ue", Pattern: "imsi-[0"},
+			err:  ErrInvalidSelector,
+		},
+		{
+			name: "bad regex",
+			req:  models.BulkRequest{NodeType: "ue", Regex: "imsi-(0"},
+			err:  ErrInvalidSelector,
+		},
+		{
+			name: "unregistered node type",
+			req:  models.BulkRequest{NodeType: "amf", Pattern: "*"},
+			err:  ErrInvalidNodeType,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			names, err := store.SelectNodes(tc.req)
+			if tc.err != nil {
+				if !errors.Is(err, tc.err) {
+					t.Fatalf("got error %v, want %v", err, tc.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(names, tc.want) {
+				t.Errorf("got %v, want %v", names, tc.want)
+			}
+		})
+	}
+}
+
+func TestExecuteBulk(t *testing.T) {
+	emu := newFakeEmulator()
+	store := NewCommandStore(emu, emu, emu)
+	probe := &probeType{}
+	if err := store.RegisterNodeType(probe.spec()); err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := store.ExecuteBulk(context.Background(), models.BulkRequest{
+		NodeType:    "probe",
+		Pattern:     "*",
+		CommandPath: "probe",
+		Concurrency: 3,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.Total != 10 || response.Succeeded != 5 || response.Failed != 5 {
+		t.Errorf("got %d results, %d succeeded and %d failed, want 10, 5 and 5", response.Total, response.Succeeded, response.Failed)
+	}
+	for i, result := range response.Results {
+		name := fmt.Sprintf("node%d", i)
+		wantStatus := models.StatusSuccess
+		if i%2 == 1 {
+			wantStatus = models.StatusFailure
+		}
+		if result.NodeName != name || result.Status != wantStatus {
+			t.Errorf("result %d: got %s %s, want %s %s", i, result.NodeName, result.Status, name, wantStatus)
+		}
+	}
+	if probe.peak != 3 {
+		t.Errorf("got %d probes at once, want 3", probe.peak)
+	}
+}
+
+func TestExecuteBulkUnknownNode(t *testing.T) {
+	emu := newFakeEmulator()
+	store := NewCommandStore(emu, emu, emu)
+
+	response, err := store.ExecuteBulk(context.Background(), models.BulkRequest{
+		NodeType:    "ue",
+		Names:       []string{"imsi-001010000000001", "imsi-999990000000000"},
+		CommandPath: "register",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.Total != 2 || response.Succeeded != 1 || response.Failed != 1 {
+		t.Fatalf("got %d results, %d succeeded and %d failed, want 2, 1 and 1", response.Total, response.Succeeded, response.Failed)
+	}
+	if failed := response.Results[1]; failed.NodeName != "imsi-999990000000000" || failed.ErrorCode != models.ErrCodeNodeNotFound {
+		t.Errorf("got %s %s, want imsi-999990000000000 %s", failed.NodeName, failed.ErrorCode, models.ErrCodeNodeNotFound)
+	}
+}
+
+func TestExecuteBulkAuthorized(t *testing.T) {
+	emu := newFakeEmulator()
+	store := NewCommandStore(emu, emu, emu)
+	store.SetAccessPolicy(DefaultAccessPolicy())
+
+	req := models.BulkRequest{NodeType: "ue", Pattern: "*", CommandPath: "register"}
+	as := func(role Role) context.Context {
+		return context.WithValue(context.Background(), PrincipalKey, Principal{Subject: "dave", Role: role})
+	}
+
+	for _, tc := range []struct {
+		name string
+		ctx  context.Context
+		err  error
+	}{
+		{"anonymous", context.Background(), ErrNoCredentials},
+		{"viewer", as(RoleViewer), ErrForbidden},
+		{"operator", as(RoleOperator), nil},
+	} {
+		response, err := store.ExecuteBulk(tc.ctx, req)
+		if !errors.Is(err, tc.err) {
+			t.Errorf("%s: got error %v, want %v", tc.name, err, tc.err)
+			continue
+		}
+		if tc.err != nil && len(response.Results) > 0 {
+			t.Errorf("%s: got %d results from a denied request", tc.name, len(response.Results))
+		}
+		if tc.err == nil && response.Succeeded != 2 {
+			t.Errorf("%s: got %d succeeded, want 2", tc.name, response.Succeeded)
+		}
+	}
+}
@@ -4,7 +4,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log"
+	"net/http"
+	"runtime/debug"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/TutuanHo03/remote-control/models"
 
@@ -29,39 +35,101 @@ type GnbApi interface {
 	ReleaseSession(ueId string, sessionId uint8) bool
 }
 
-// CommandStore manages command definitions and executions
-type CommandStore struct {
-	eApi EmulatorApi
-	uApi UeApi
-	gApi GnbApi
+// NodeChangeNotifier is implemented by emulators that report added and
+// removed nodes as they happen, instead of waiting for the next node sync
+type NodeChangeNotifier interface {
+	OnNodesChanged(callback func())
+}
+
+// UeProvider resolves the UeApi of a UE node by its name
+type UeProvider interface {
+	GetUe(name string) (UeApi, error)
+}
+
+// GnbProvider resolves the GnbApi of a gNB node by its name
+type GnbProvider interface {
+	GetGnb(name string) (GnbApi, error)
+}
+
+var (
+	// ErrNodeNotFound is returned when the selected node no longer exists
+	ErrNodeNotFound = errors.New("node not found")
+	// ErrInvalidNodeType is returned for requests targeting an unknown node type
+	ErrInvalidNodeType = errors.New("invalid node type")
+	// ErrCommandTimeout is returned when a command exceeds its deadline
+	ErrCommandTimeout = errors.New("command timed out")
+	// ErrCommandCanceled is returned when the caller cancels a running command
+	ErrCommandCanceled = errors.New("command canceled")
+	// ErrCommandPanicked is returned when a command action panics
+	ErrCommandPanicked = errors.New("command panicked")
+	// ErrNoResult is returned when a command finishes without producing a result
+	ErrNoResult = errors.New("command produced no result")
+	// ErrUnknownCommand is returned for commands the node type does not define
+	ErrUnknownCommand = errors.New("unknown command")
+	// ErrIncompleteCommand is returned for command groups run without a subcommand
+	ErrIncompleteCommand = errors.New("incomplete command")
+	// ErrUnknownFlag is returned for flags the command does not define
+	ErrUnknownFlag = errors.New("unknown flag")
+	// ErrInvalidFlag is returned for flags with a missing or malformed value
+	ErrInvalidFlag = errors.New("invalid flag")
+	// ErrInvalidRequest is returned for requests that cannot be parsed
+	ErrInvalidRequest = errors.New("invalid request")
+)
+
+// DefaultCommandTimeout is the deadline of commands without a specific timeout
+const DefaultCommandTimeout = 30 * time.Second
 
-	// Command definitions
-	emuCmd *cli.Command
-	ueCmd  *cli.Command
-	gnbCmd *cli.Command
+// stoppedResultGrace is how long a canceled or timed out command may take to
+// report its partial result
+const stoppedResultGrace = 100 * time.Millisecond
 
-	// Cache of command info by node type
-	commandCache map[string][]models.CommandInfo
+// CommandStore manages command definitions and executions
+type CommandStore struct {
+	eApi        EmulatorApi
+	ueProvider  UeProvider
+	gnbProvider GnbProvider
+
+	// Registered node types by name, and their registration order
+	typesMu   sync.RWMutex
+	types     map[string]*registeredType
+	typeOrder []string
+
+	// Roles required to run commands, nil when callers are not checked
+	policyMu sync.RWMutex
+	policy   *AccessPolicy
+
+	// Callbacks run when commands add or remove nodes
+	nodesMu      sync.Mutex
+	nodesChanged []func()
+
+	// Execution deadlines, per "<node-type> <command-path>" and default
+	timeoutMu      sync.RWMutex
+	timeouts       map[string]time.Duration
+	defaultTimeout time.Duration
+
+	auditMu sync.RWMutex
+	audit   *AuditLog
 }
 
 // NewCommandStore creates a new command store
-func NewCommandStore(eApi EmulatorApi, uApi UeApi, gApi GnbApi) *CommandStore {
+func NewCommandStore(eApi EmulatorApi, ueProvider UeProvider, gnbProvider GnbProvider) *CommandStore {
 	store := &CommandStore{
-		eApi:         eApi,
-		uApi:         uApi,
-		gApi:         gApi,
-		commandCache: make(map[string][]models.CommandInfo),
+		eApi:           eApi,
+		ueProvider:     ueProvider,
+		gnbProvider:    gnbProvider,
+		types:          make(map[string]*registeredType),
+		timeouts:       make(map[string]time.Duration),
+		defaultTimeout: DefaultCommandTimeout,
 	}
 
-	store.initCommands()
+	store.registerBuiltinTypes()
 
 	return store
 }
 
-// initCommands initializes all command definitions
-func (s *CommandStore) initCommands() {
-	// Initialize emulator commands
-	s.emuCmd = &cli.Command{
+// newEmulatorCommand builds the emulator command tree
+func (s *CommandStore) newEmulatorCommand() *cli.Command {
+	return &cli.Command{
 		Name:        "emulator",
 		Usage:       "Emulator management commands",
 		Description: "Commands to manage and interact with the emulator",
@@ -70,9 +138,10 @@ func (s *CommandStore) initCommands() {
 				Name:  "list-ue",
 				Usage: "List all UEs",
 				Action: func(ctx context.Context, cmd *cli.Command) error {
-					rspCh := ctx.Value("rsp").(chan string)
 					ues := s.eApi.ListUes()
-					rspCh <- strings.Join(ues, "\n")
+					Succeed(ctx, strings.Join(ues, "\n"), map[string]string{
+						"count": strconv.Itoa(len(ues)),
+					})
 					return nil
 				},
 			},
@@ -80,9 +149,10 @@ func (s *CommandStore) initCommands() {
 				Name:  "list-gnb",
 				Usage: "List all GnBs",
 				Action: func(ctx context.Context, cmd *cli.Command) error {
-					rspCh := ctx.Value("rsp").(chan string)
 					gnbs := s.eApi.ListGnbs()
-					rspCh <- strings.Join(gnbs, "\n")
+					Succeed(ctx, strings.Join(gnbs, "\n"), map[string]string{
+						"count": strconv.Itoa(len(gnbs)),
+					})
 					return nil
 				},
 			},
@@ -91,6 +161,9 @@ func (s *CommandStore) initCommands() {
 				Usage:       "Add a new UE with SUPI",
 				ArgsUsage:   "<supi>",
 				Description: "Add a new UE to the emulator with the specified SUPI",
+				Metadata: withSchema(CommandSchema{
+					Args: []models.ArgInfo{supiArg},
+				}),
 				Flags: []cli.Flag{
 					&cli.BoolFlag{
 						Name:  "register",
@@ -98,28 +171,32 @@ func (s *CommandStore) initCommands() {
 					},
 				},
 				Action: func(ctx context.Context, cmd *cli.Command) error {
-					rspCh := ctx.Value("rsp").(chan string)
-					args := cmd.Args().Slice()
-					if len(args) < 1 {
-						rspCh <- "Error: SUPI is required"
-						return nil
-					}
-					supi := args[0]
+					supi := cmd.Args().First()
 					register := cmd.Bool("register")
-					success := s.eApi.AddUe(supi, register)
-					if success {
-						rspCh <- fmt.Sprintf("UE %s added successfully to emulator", supi)
+					data := map[string]string{
+						"supi":     supi,
+						"register": strconv.FormatBool(register),
+					}
+					if s.eApi.AddUe(supi, register) {
+						s.notifyNodesChanged()
+						Succeed(ctx, fmt.Sprintf("UE %s added successfully to emulator", supi), data)
 					} else {
-						rspCh <- fmt.Sprintf("Failed to add UE %s to emulator", supi)
+						Fail(ctx, models.ErrCodeOperationFailed, fmt.Sprintf("Failed to add UE %s to emulator", supi), data)
 					}
 					return nil
 				},
 			},
+			s.newRemoveUeCommand(),
+			s.newAddUeRangeCommand(),
+			s.newAddGnbCommand(),
+			s.newRemoveGnbCommand(),
 		},
 	}
+}
 
-	// Initialize UE commands
-	s.ueCmd = &cli.Command{
+// newUeCommand builds the UE command tree
+func (s *CommandStore) newUeCommand() *cli.Command {
+	return &cli.Command{
 		Name:        "ue",
 		Usage:       "UE management commands",
 		Description: "Commands to manage and interact with UEs",
@@ -128,6 +205,9 @@ func (s *CommandStore) initCommands() {
 				Name:        "register",
 				Usage:       "Register UE to the network",
 				Description: "Register the UE to the network with optional emergency services",
+				Metadata: withSchema(CommandSchema{
+					Args: []models.ArgInfo{},
+				}),
 				Flags: []cli.Flag{
 					&cli.BoolFlag{
 						Name:  "emergency",
@@ -135,22 +215,19 @@ func (s *CommandStore) initCommands() {
 					},
 				},
 				Action: func(ctx context.Context, cmd *cli.Command) error {
-					rspCh := ctx.Value("rsp").(chan string)
-					nodeName, hasNode := GetNodeName(ctx)
+					nodeName, _ := GetNodeName(ctx)
 					isEmergency := cmd.Bool("emergency")
-					success := s.uApi.Register(isEmergency)
-					if success {
-						if hasNode {
-							rspCh <- fmt.Sprintf("UE %s registered successfully", nodeName)
-						} else {
-							rspCh <- "UE registered successfully"
-						}
+					data := map[string]string{
+						"emergency": strconv.FormatBool(isEmergency),
+					}
+					ue, ok := ueApi(ctx)
+					if !ok {
+						return nil
+					}
+					if ue.Register(isEmergency) {
+						Succeed(ctx, fmt.Sprintf("UE %s registered successfully", nodeName), data)
 					} else {
-						if hasNode {
-							rspCh <- fmt.Sprintf("Failed to register UE %s", nodeName)
-						} else {
-							rspCh <- "Failed to register UE"
-						}
+						Fail(ctx, models.ErrCodeOperationFailed, fmt.Sprintf("Failed to register UE %s", nodeName), data)
 					}
 					return nil
 				},
@@ -159,6 +236,10 @@ func (s *CommandStore) initCommands() {
 				Name:        "deregister",
 				Usage:       "Deregister UE from the network",
 				Description: "Deregister the UE from the network with specified type",
+				Metadata: withSchema(CommandSchema{
+					Args:  []models.ArgInfo{},
+					Flags: map[string]models.ValueRules{"type": intRange(0, 3)},
+				}),
 				Flags: []cli.Flag{
 					&cli.IntFlag{
 						Name:  "type",
@@ -167,75 +248,103 @@ func (s *CommandStore) initCommands() {
 					},
 				},
 				Action: func(ctx context.Context, cmd *cli.Command) error {
-					rspCh := ctx.Value("rsp").(chan string)
-					nodeName, hasNode := GetNodeName(ctx)
+					nodeName, _ := GetNodeName(ctx)
 					deregType := uint8(cmd.Int("type"))
-					success := s.uApi.Deregister(deregType)
-					if success {
-						if hasNode {
-							rspCh <- fmt.Sprintf("UE %s deregistered successfully", nodeName)
-						} else {
-							rspCh <- "UE deregistered successfully"
-						}
+					data := map[string]string{
+						"type": strconv.Itoa(int(deregType)),
+					}
+					ue, ok := ueApi(ctx)
+					if !ok {
+						return nil
+					}
+					if ue.Deregister(deregType) {
+						Succeed(ctx, fmt.Sprintf("UE %s deregistered successfully", nodeName), data)
 					} else {
-						if hasNode {
-							rspCh <- fmt.Sprintf("Failed to deregister UE %s", nodeName)
-						} else {
-							rspCh <- "Failed to deregister UE"
-						}
+						Fail(ctx, models.ErrCodeOperationFailed, fmt.Sprintf("Failed to deregister UE %s", nodeName), data)
 					}
 					return nil
 				},
 			},
+			s.newCreateSessionCommand("create-session"),
+			s.newUeStatusCommand(),
+			s.newListSessionsCommand("list-sessions"),
+			s.newShowConfigCommand(),
 			{
-				Name:        "create-session",
-				Usage:       "Create a new session",
-				Description: "Create a new PDU session with specified parameters",
-				Flags: []cli.Flag{
-					&cli.StringFlag{
-						Name:  "slice",
-						Usage: "Network slice",
-						Value: "default",
-					},
-					&cli.StringFlag{
-						Name:  "dn",
-						Usage: "Data Network name",
-						Value: "internet",
-					},
-					&cli.IntFlag{
-						Name:  "type",
-						Usage: "Session type (0-3)",
-						Value: 0,
-					},
-				},
-				Action: func(ctx context.Context, cmd *cli.Command) error {
-					rspCh := ctx.Value("rsp").(chan string)
-					nodeName, hasNode := GetNodeName(ctx)
-					slice := cmd.String("slice")
-					dn := cmd.String("dn")
-					sessionType := uint8(cmd.Int("type"))
-					success := s.uApi.CreateSession(slice, dn, sessionType)
-					if success {
-						if hasNode {
-							rspCh <- fmt.Sprintf("Session created successfully for UE %s", nodeName)
-						} else {
-							rspCh <- "Session created successfully"
-						}
-					} else {
-						if hasNode {
-							rspCh <- fmt.Sprintf("Failed to create session for UE %s", nodeName)
-						} else {
-							rspCh <- "Failed to create session"
-						}
-					}
-					return nil
+				Name:        "session",
+				Usage:       "PDU session commands",
+				Description: "Manage the PDU sessions of the UE",
+				Commands: []*cli.Command{
+					s.newCreateSessionCommand("create"),
+					s.newListSessionsCommand("list"),
+					s.newReleaseSessionCommand(),
 				},
 			},
 		},
 	}
+}
+
+// newCreateSessionCommand builds the PDU session creation command, available
+// as create-session and as session create
+func (s *CommandStore) newCreateSessionCommand(name string) *cli.Command {
+	return &cli.Command{
+		Name:        name,
+		Usage:       "Create a new session",
+		Description: "Create a new PDU session with specified parameters",
+		Metadata: withSchema(CommandSchema{
+			Args:  []models.ArgInfo{},
+			Flags: map[string]models.ValueRules{"type": intRange(0, 3)},
+		}),
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "slice",
+				Usage: "Network slice",
+				Value: "default",
+			},
+			&cli.StringFlag{
+				Name:  "dn",
+				Usage: "Data Network name",
+				Value: "internet",
+			},
+			&cli.IntFlag{
+				Name:  "type",
+				Usage: "Session type (0-3)",
+				Value: 0,
+			},
+		},
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			nodeName, _ := GetNodeName(ctx)
+			slice := cmd.String("slice")
+			dn := cmd.String("dn")
+			sessionType := uint8(cmd.Int("type"))
+			data := map[string]string{
+				"slice": slice,
+				"dn":    dn,
+				"type":  strconv.Itoa(int(sessionType)),
+			}
+			ue, ok := ueApi(ctx)
+			if !ok {
+				return nil
+			}
+			if ue.CreateSession(slice, dn, sessionType) {
+				Succeed(ctx, fmt.Sprintf("Session created successfully for UE %s", nodeName), data)
+			} else {
+				Fail(ctx, models.ErrCodeOperationFailed, fmt.Sprintf("Failed to create session for UE %s", nodeName), data)
+			}
+			return nil
+		},
+	}
+}
+
+// ueIDArg is the UE argument of the gNB commands
+var ueIDArg = models.ArgInfo{
+	Name:     "ue-id",
+	Usage:    "Identifier of the UE",
+	Required: true,
+}
 
-	// Initialize GNB commands
-	s.gnbCmd = &cli.Command{
+// newGnbCommand builds the gNB command tree
+func (s *CommandStore) newGnbCommand() *cli.Command {
+	return &cli.Command{
 		Name:        "gnb",
 		Usage:       "gNB management commands",
 		Description: "Commands to manage and interact with gNBs",
@@ -245,28 +354,23 @@ func (s *CommandStore) initCommands() {
 				Usage:       "Release a UE from the gNB",
 				ArgsUsage:   "<ue-id>",
 				Description: "Release a UE connection from the gNB",
+				Metadata: withSchema(CommandSchema{
+					Args: []models.ArgInfo{ueIDArg},
+				}),
 				Action: func(ctx context.Context, cmd *cli.Command) error {
-					rspCh := ctx.Value("rsp").(chan string)
-					nodeName, hasNode := GetNodeName(ctx)
-					args := cmd.Args().Slice()
-					if len(args) < 1 {
-						rspCh <- "Error: UE ID is required"
+					nodeName, _ := GetNodeName(ctx)
+					ueId := cmd.Args().First()
+					data := map[string]string{
+						"ueId": ueId,
+					}
+					gnb, ok := gnbApi(ctx)
+					if !ok {
 						return nil
 					}
-					ueId := args[0]
-					success := s.gApi.ReleaseUe(ueId)
-					if success {
-						if hasNode {
-							rspCh <- fmt.Sprintf("UE %s released successfully from gNB %s", ueId, nodeName)
-						} else {
-							rspCh <- fmt.Sprintf("UE %s released successfully", ueId)
-						}
+					if gnb.ReleaseUe(ueId) {
+						Succeed(ctx, fmt.Sprintf("UE %s released successfully from gNB %s", ueId, nodeName), data)
 					} else {
-						if hasNode {
-							rspCh <- fmt.Sprintf("Failed to release UE %s from gNB %s", ueId, nodeName)
-						} else {
-							rspCh <- fmt.Sprintf("Failed to release UE %s", ueId)
-						}
+						Fail(ctx, models.ErrCodeOperationFailed, fmt.Sprintf("Failed to release UE %s from gNB %s", ueId, nodeName), data)
 					}
 					return nil
 				},
@@ -276,6 +380,10 @@ func (s *CommandStore) initCommands() {
 				Usage:       "Release a session",
 				ArgsUsage:   "<ue-id>",
 				Description: "Release a PDU session for the specified UE",
+				Metadata: withSchema(CommandSchema{
+					Args:  []models.ArgInfo{ueIDArg},
+					Flags: map[string]models.ValueRules{"id": intRange(1, 15)},
+				}),
 				Flags: []cli.Flag{
 					&cli.IntFlag{
 						Name:  "id",
@@ -284,43 +392,34 @@ func (s *CommandStore) initCommands() {
 					},
 				},
 				Action: func(ctx context.Context, cmd *cli.Command) error {
-					rspCh := ctx.Value("rsp").(chan string)
-					nodeName, hasNode := GetNodeName(ctx)
-					args := cmd.Args().Slice()
-					if len(args) < 1 {
-						rspCh <- "Error: UE ID is required"
+					nodeName, _ := GetNodeName(ctx)
+					ueId := cmd.Args().First()
+					sessionId := uint8(cmd.Int("id"))
+					data := map[string]string{
+						"ueId":      ueId,
+						"sessionId": strconv.Itoa(int(sessionId)),
+					}
+					gnb, ok := gnbApi(ctx)
+					if !ok {
 						return nil
 					}
-					ueId := args[0]
-					sessionId := uint8(cmd.Int("id"))
-					success := s.gApi.ReleaseSession(ueId, sessionId)
-					if success {
-						if hasNode {
-							rspCh <- fmt.Sprintf("Session %d for UE %s released successfully from gNB %s",
-								sessionId, ueId, nodeName)
-						} else {
-							rspCh <- fmt.Sprintf("Session %d for UE %s released successfully",
-								sessionId, ueId)
-						}
+					if gnb.ReleaseSession(ueId, sessionId) {
+						Succeed(ctx, fmt.Sprintf("Session %d for UE %s released successfully from gNB %s",
+							sessionId, ueId, nodeName), data)
 					} else {
-						if hasNode {
-							rspCh <- fmt.Sprintf("Failed to release session %d for UE %s from gNB %s",
-								sessionId, ueId, nodeName)
-						} else {
-							rspCh <- fmt.Sprintf("Failed to release session %d for UE %s",
-								sessionId, ueId)
-						}
+						Fail(ctx, models.ErrCodeOperationFailed, fmt.Sprintf("Failed to release session %d for UE %s from gNB %s",
+							sessionId, ueId, nodeName), data)
 					}
 					return nil
 				},
 			},
+			s.newListGnbUesCommand(),
+			s.newNgStatusCommand(),
+			s.newGnbShowConfigCommand(),
+			s.newNgProcedureCommand("ng-setup", "NG Setup", NgController.NgSetup),
+			s.newNgProcedureCommand("ng-reset", "NG Reset", NgController.NgReset),
 		},
 	}
-
-	// Build and cache CommandInfo objects
-	s.commandCache["emulator"] = s.convertCommandInfos(s.emuCmd.Commands)
-	s.commandCache["ue"] = s.convertCommandInfos(s.ueCmd.Commands)
-	s.commandCache["gnb"] = s.convertCommandInfos(s.gnbCmd.Commands)
 }
 
 // convertCommandInfos converts CLI commands to CommandInfo objects
@@ -328,31 +427,41 @@ func (s *CommandStore) convertCommandInfos(commands []*cli.Command) []models.Com
 	result := make([]models.CommandInfo, 0, len(commands))
 
 	for _, cmd := range commands {
+		schema := commandSchema(cmd)
 		info := models.CommandInfo{
 			Name:        cmd.Name,
 			Usage:       cmd.Usage,
 			Description: cmd.Description,
 			ArgsUsage:   cmd.ArgsUsage,
+			Args:        schema.Args,
+			Subcommands: s.convertCommandInfos(cmd.Commands),
 		}
 
 		// Process flags
 		for _, flag := range cmd.Flags {
 			flagInfo := models.FlagInfo{
-				Name:  strings.Join(flag.Names(), ", "),
-				Usage: flagUsage(flag),
+				Name:       strings.Join(flag.Names(), ", "),
+				Usage:      flagUsage(flag),
+				ValueRules: schema.Flags[flag.Names()[0]],
+			}
+			if req, ok := flag.(cli.RequiredFlag); ok {
+				flagInfo.Required = req.IsRequired()
 			}
 
 			// Set default value text based on flag type
 			switch f := flag.(type) {
 			case *cli.StringFlag:
+				flagInfo.Type = models.FlagTypeString
 				flagInfo.DefaultText = f.Value
 			case *cli.BoolFlag:
+				flagInfo.Type = models.FlagTypeBool
 				if f.Value {
 					flagInfo.DefaultText = "true"
 				} else {
 					flagInfo.DefaultText = "false"
 				}
 			case *cli.IntFlag:
+				flagInfo.Type = models.FlagTypeInt
 				if f.Value != 0 {
 					flagInfo.DefaultText = fmt.Sprintf("%d", f.Value)
 				}
@@ -383,110 +492,251 @@ func flagUsage(flag cli.Flag) string {
 
 // GetCommandsForNodeType returns command infos for a node type
 func (s *CommandStore) GetCommandsForNodeType(nodeType string) []models.CommandInfo {
-	if commands, ok := s.commandCache[nodeType]; ok {
-		return commands
+	if rt, ok := s.nodeType(nodeType); ok {
+		return rt.commands
 	}
 	return []models.CommandInfo{}
 }
 
 // GetObjectsOfType returns objects of a specific type
 func (s *CommandStore) GetObjectsOfType(objectType string) ([]string, error) {
-	switch objectType {
-	case "ue":
-		return s.eApi.ListUes(), nil
-	case "gnb":
-		return s.eApi.ListGnbs(), nil
-	case "emulator":
-		return []string{"emulator"}, nil
-	default:
-		return nil, errors.New("invalid object type")
+	rt, ok := s.nodeType(objectType)
+	if !ok {
+		return nil, s.errUnregisteredType(objectType)
+	}
+	if rt.spec.Singleton {
+		return []string{rt.spec.Name}, nil
 	}
+	return rt.spec.ListNodes(), nil
 }
 
-// ExecuteCommand executes a command request
-func (s *CommandStore) ExecuteCommand(req models.CommandRequest) (models.CommandResponse, error) {
-	// Check for help flag
-	hasHelpFlag := false
-	for _, arg := range req.Args {
-		if arg == "--help" || arg == "-h" {
-			hasHelpFlag = true
-			break
-		}
+// SetDefaultTimeout sets the deadline of commands without a specific timeout
+func (s *CommandStore) SetDefaultTimeout(timeout time.Duration) {
+	s.timeoutMu.Lock()
+	defer s.timeoutMu.Unlock()
+	s.defaultTimeout = timeout
+}
+
+// SetCommandTimeout sets the deadline of one command of a node type
+func (s *CommandStore) SetCommandTimeout(nodeType, commandPath string, timeout time.Duration) {
+	s.timeoutMu.Lock()
+	defer s.timeoutMu.Unlock()
+	s.timeouts[nodeType+" "+commandPath] = timeout
+}
+
+// commandTimeout returns the deadline of a command
+func (s *CommandStore) commandTimeout(nodeType, commandPath string) time.Duration {
+	s.timeoutMu.RLock()
+	defer s.timeoutMu.RUnlock()
+	if timeout, ok := s.timeouts[nodeType+" "+commandPath]; ok {
+		return timeout
 	}
+	return s.defaultTimeout
+}
+
+// ExecuteCommand executes a command request, giving up when ctx is done or
+// the command deadline expires, and records it in the audit log
+func (s *CommandStore) ExecuteCommand(ctx context.Context, req models.CommandRequest) (models.CommandResponse, error) {
+	start := time.Now()
+	response, err := s.executeCommand(ctx, req, start)
+	s.auditCommand(ctx, req, start, response, err)
+	return response, err
+}
 
-	if hasHelpFlag {
+// executeCommand parses, checks and runs a command request
+func (s *CommandStore) executeCommand(ctx context.Context, req models.CommandRequest, start time.Time) (models.CommandResponse, error) {
+	line, err := s.parseCommandLine(req)
+	if err != nil {
+		return models.CommandResponse{}, err
+	}
+	req.CommandPath = line.Path
+
+	// Help is authorized like the command, callers only learn about the
+	// commands they may run
+	if err := s.authorize(ctx, req.NodeType, line.Path); err != nil {
+		return models.CommandResponse{}, err
+	}
+
+	if line.Help {
 		// Generate help text directly
 		helpText := s.GenerateCommandHelp(req.NodeType, req.CommandPath)
-		return models.CommandResponse{
-			Response: helpText,
-		}, nil
+		return newCommandResponse(req, models.CommandResult{
+			Status:  models.StatusSuccess,
+			Message: helpText,
+		}, start), nil
+	}
+
+	rt, ok := s.nodeType(req.NodeType)
+	if !ok {
+		return models.CommandResponse{}, s.errUnregisteredType(req.NodeType)
+	}
+	if err := validateCommandLine(req.NodeType, line, rt.patterns); err != nil {
+		return models.CommandResponse{}, err
 	}
 
 	// Create response channel
-	rspCh := make(chan string, 1)
-	ctx := context.WithValue(context.Background(), "rsp", rspCh)
+	rspCh := make(chan models.CommandResult, 1)
+	ctx = context.WithValue(ctx, "rsp", rspCh)
 	ctx = context.WithValue(ctx, "nodename", req.NodeName)
 
-	// Process command args
-	var cmdArgs []string
-	if req.RawCommand != "" {
-		cmdArgs = strings.Fields(req.RawCommand)
-	} else {
-		cmdArgs = []string{req.CommandPath}
-		cmdArgs = append(cmdArgs, req.Args...)
-	}
-
-	// Execute appropriate command
-	var err error
-	switch req.NodeType {
-	case "emulator":
-		err = s.emuCmd.Run(ctx, append([]string{"emulator"}, cmdArgs...))
-	case "ue":
-		err = s.ueCmd.Run(ctx, append([]string{"ue"}, cmdArgs...))
-	case "gnb":
-		err = s.gnbCmd.Run(ctx, append([]string{"gnb"}, cmdArgs...))
-	default:
-		return models.CommandResponse{}, errors.New("invalid node type")
+	// Resolve the selected node and build a fresh command tree
+	if rt.spec.Resolve != nil {
+		node, err := rt.spec.Resolve(req.NodeName)
+		if err != nil {
+			return models.CommandResponse{}, nodeNotFound(req.NodeType, req.NodeName, err)
+		}
+		if node == nil {
+			return models.CommandResponse{}, nodeNotFound(req.NodeType, req.NodeName, ErrNodeNotFound)
+		}
+		ctx = context.WithValue(ctx, NodeKey, node)
 	}
 
+	timeout := s.commandTimeout(req.NodeType, req.CommandPath)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	run, err := line.bind(ctx, rt.spec.Commands())
 	if err != nil {
 		return models.CommandResponse{}, err
 	}
 
-	// Get response from channel
-	response := <-rspCh
+	// Run in the background so a stuck procedure cannot hold the caller;
+	// the emulator call itself keeps running until it returns
+	errCh := make(chan error, 1)
+	go func() {
+		// The recovery middleware only covers the request goroutine
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("Command %s %s panicked: %v\n%s", req.NodeType, req.CommandPath, r, debug.Stack())
+				errCh <- fmt.Errorf("%w: %s %s: %v", ErrCommandPanicked, req.NodeType, req.CommandPath, r)
+			}
+		}()
+		errCh <- run()
+	}()
+
+	select {
+	case result := <-rspCh:
+		return newCommandResponse(req, result, start), nil
+	case err := <-errCh:
+		if err != nil {
+			return models.CommandResponse{}, err
+		}
+		// The action may have answered right before returning
+		select {
+		case result := <-rspCh:
+			return newCommandResponse(req, result, start), nil
+		default:
+			return models.CommandResponse{}, ErrNoResult
+		}
+	case <-ctx.Done():
+		// Commands that stop early report what they did before giving up
+		if result, ok := awaitStoppedResult(rspCh, errCh); ok {
+			return newCommandResponse(req, result, start), nil
+		}
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return models.CommandResponse{}, fmt.Errorf("%w after %s", ErrCommandTimeout, timeout)
+		}
+		return models.CommandResponse{}, ErrCommandCanceled
+	}
+}
+
+// awaitStoppedResult waits a short grace period for the result of a command
+// whose context is done, for actions that answer with their partial progress
+func awaitStoppedResult(rspCh <-chan models.CommandResult, errCh <-chan error) (models.CommandResult, bool) {
+	timer := time.NewTimer(stoppedResultGrace)
+	defer timer.Stop()
+
+	select {
+	case result := <-rspCh:
+		return result, true
+	case <-errCh:
+		select {
+		case result := <-rspCh:
+			return result, true
+		default:
+			return models.CommandResult{}, false
+		}
+	case <-timer.C:
+		return models.CommandResult{}, false
+	}
+}
+
+// newCommandResponse fills in the request details of a result and wraps it
+// in a response, keeping the plain Response text for older clients
+func newCommandResponse(req models.CommandRequest, result models.CommandResult, start time.Time) models.CommandResponse {
+	result.NodeType = req.NodeType
+	result.NodeName = req.NodeName
+	result.CommandPath = req.CommandPath
+	result.DurationMs = time.Since(start).Milliseconds()
 
 	return models.CommandResponse{
-		Response: response,
-	}, nil
+		Response: result.Message,
+		Result:   &result,
+	}
+}
+
+// commandErrorResponse maps an ExecuteCommand error to its HTTP status and response
+func commandErrorResponse(req models.CommandRequest, err error) (int, models.CommandResponse) {
+	var validationErr *ValidationError
+	switch {
+	case errors.Is(err, ErrCommandTimeout):
+		return http.StatusGatewayTimeout, errorResponse(req, models.ErrCodeTimeout, err)
+	case errors.Is(err, ErrCommandCanceled):
+		return http.StatusRequestTimeout, errorResponse(req, models.ErrCodeCanceled, err)
+	case errors.Is(err, ErrNodeNotFound):
+		return http.StatusNotFound, errorResponse(req, models.ErrCodeNodeNotFound, err)
+	case errors.Is(err, ErrNoCredentials), errors.Is(err, ErrInvalidCredentials):
+		return http.StatusUnauthorized, errorResponse(req, models.ErrCodeUnauthorized, err)
+	case errors.Is(err, ErrForbidden):
+		return http.StatusForbidden, errorResponse(req, models.ErrCodeForbidden, err)
+	case errors.Is(err, ErrInvalidNodeType):
+		return http.StatusBadRequest, errorResponse(req, models.ErrCodeInvalidNodeType, err)
+	case errors.Is(err, ErrUnknownCommand), errors.Is(err, ErrIncompleteCommand):
+		return http.StatusBadRequest, errorResponse(req, models.ErrCodeInvalidCommand, err)
+	case errors.Is(err, ErrUnknownFlag), errors.Is(err, ErrInvalidFlag):
+		return http.StatusBadRequest, errorResponse(req, models.ErrCodeInvalidFlag, err)
+	case errors.Is(err, ErrInvalidRequest):
+		return http.StatusBadRequest, errorResponse(req, models.ErrCodeInvalidRequest, err)
+	case errors.As(err, &validationErr):
+		response := errorResponse(req, models.ErrCodeValidation, err)
+		response.Result.Message = validationErr.summary()
+		response.Result.Errors = validationErr.Errors
+		return http.StatusBadRequest, response
+	default:
+		return http.StatusInternalServerError, errorResponse(req, models.ErrCodeInvalidCommand, err)
+	}
+}
+
+// errorResponse builds the response returned when a request could not be executed
+func errorResponse(req models.CommandRequest, code string, err error) models.CommandResponse {
+	return models.CommandResponse{
+		Error: err.Error(),
+		Result: &models.CommandResult{
+			Status:      models.StatusError,
+			ErrorCode:   code,
+			NodeType:    req.NodeType,
+			NodeName:    req.NodeName,
+			CommandPath: req.CommandPath,
+			Message:     err.Error(),
+		},
+	}
+}
+
+// commandTree returns the command definitions of a node type
+func (s *CommandStore) commandTree(nodeType string) *cli.Command {
+	if rt, ok := s.nodeType(nodeType); ok {
+		return rt.tree
+	}
+	return nil
 }
 
 // GenerateCommandHelp generates help text for a command
-func (s *CommandStore) GenerateCommandHelp(nodeType, commandName string) string {
+func (s *CommandStore) GenerateCommandHelp(nodeType, commandPath string) string {
 	var cmd *cli.Command
-
-	// Find the command
-	switch nodeType {
-	case "emulator":
-		for _, c := range s.emuCmd.Commands {
-			if c.Name == commandName {
-				cmd = c
-				break
-			}
-		}
-	case "ue":
-		for _, c := range s.ueCmd.Commands {
-			if c.Name == commandName {
-				cmd = c
-				break
-			}
-		}
-	case "gnb":
-		for _, c := range s.gnbCmd.Commands {
-			if c.Name == commandName {
-				cmd = c
-				break
-			}
+	path := strings.Fields(commandPath)
+	if root := s.commandTree(nodeType); root != nil && len(path) > 0 {
+		if found, depth := findCommand(root, path); depth == len(path) {
+			cmd = found
 		}
 	}
 
@@ -496,7 +746,7 @@ func (s *CommandStore) GenerateCommandHelp(nodeType, commandName string) string
 
 	var sb strings.Builder
 
-	sb.WriteString(cmd.Name)
+	sb.WriteString(strings.Join(path, " "))
 	if cmd.ArgsUsage != "" {
 		sb.WriteString(" ")
 		sb.WriteString(cmd.ArgsUsage)
@@ -520,9 +770,53 @@ func (s *CommandStore) GenerateCommandHelp(nodeType, commandName string) string
 		}
 	}
 
+	if len(cmd.Commands) > 0 {
+		sb.WriteString("Commands:\n")
+		for _, sub := range cmd.Commands {
+			sb.WriteString(fmt.Sprintf("   %-16s %s\n", sub.Name, sub.Usage))
+		}
+	}
+
 	return sb.String()
 }
 
+// nodeNotFound wraps a resolver error so callers can match ErrNodeNotFound
+func nodeNotFound(nodeType, nodeName string, err error) error {
+	if errors.Is(err, ErrNodeNotFound) {
+		return fmt.Errorf("%s %s: %w", nodeType, nodeName, err)
+	}
+	return fmt.Errorf("%s %s: %w: %v", nodeType, nodeName, ErrNodeNotFound, err)
+}
+
+// ueApi returns the UE resolved for the current execution; without one the
+// command fails with NODE_NOT_FOUND and ok is false
+func ueApi(ctx context.Context) (ue UeApi, ok bool) {
+	if ue, ok = GetNode(ctx).(UeApi); !ok {
+		nodeMissing(ctx, "UE")
+	}
+	return ue, ok
+}
+
+// unsupported fails a command the node does not implement
+func unsupported(ctx context.Context, message string) {
+	Fail(ctx, models.ErrCodeUnsupported, message, nil)
+}
+
+// gnbApi returns the gNB resolved for the current execution; without one the
+// command fails with NODE_NOT_FOUND and ok is false
+func gnbApi(ctx context.Context) (gnb GnbApi, ok bool) {
+	if gnb, ok = GetNode(ctx).(GnbApi); !ok {
+		nodeMissing(ctx, "gNB")
+	}
+	return gnb, ok
+}
+
+// nodeMissing fails a command whose node was not resolved
+func nodeMissing(ctx context.Context, kind string) {
+	nodeName, _ := GetNodeName(ctx)
+	Fail(ctx, models.ErrCodeNodeNotFound, fmt.Sprintf("%s %s not found", kind, nodeName), nil)
+}
+
 func GetNodeName(ctx context.Context) (string, bool) {
 	val := ctx.Value("nodename")
 	if nodename, ok := val.(string); ok {
@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/TutuanHo03/remote-control/models"
+)
+
+// nilProvider resolves every node to nothing without an error
+type nilProvider struct{}
+
+func (nilProvider) GetUe(name string) (UeApi, error)   { return nil, nil }
+func (nilProvider) GetGnb(name string) (GnbApi, error) { return nil, nil }
+
+func TestExecuteCommandNilNode(t *testing.T) {
+	emu := newFakeEmulator()
+	store := NewCommandStore(emu, nilProvider{}, nilProvider{})
+
+	for _, req := range []models.CommandRequest{
+		{NodeType: "ue", NodeName: "imsi-001010000000001", RawCommand: "register"},
+		{NodeType: "gnb", NodeName: "gnb1", RawCommand: "release-ue 1"},
+	} {
+		_, err := store.ExecuteCommand(context.Background(), req)
+		if !errors.Is(err, ErrNodeNotFound) {
+			t.Errorf("%s %s: got error %v, want %v", req.NodeType, req.RawCommand, err, ErrNodeNotFound)
+		}
+	}
+}
+
+func TestNodeApiMissing(t *testing.T) {
+	rspCh := make(chan models.CommandResult, 2)
+	ctx := context.WithValue(context.WithValue(context.Background(), "rsp", rspCh), "nodename", "imsi-001010000000001")
+
+	if _, ok := ueApi(ctx); ok {
+		t.Error("got a UE without a resolved node")
+	}
+	if _, ok := gnbApi(ctx); ok {
+		t.Error("got a gNB without a resolved node")
+	}
+	for range 2 {
+		if result := <-rspCh; result.Status != models.StatusFailure || result.ErrorCode != models.ErrCodeNodeNotFound {
+			t.Errorf("got %s %s, want %s %s", result.Status, result.ErrorCode, models.StatusFailure, models.ErrCodeNodeNotFound)
+		}
+	}
+}
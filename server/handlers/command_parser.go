@@ -0,0 +1,273 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/TutuanHo03/remote-control/models"
+
+	shlex "github.com/flynn-archive/go-shlex"
+	"github.com/urfave/cli/v3"
+)
+
+// commandLine is a command request parsed and checked against the command definition
+type commandLine struct {
+	Command *cli.Command      // Definition of the command
+	Path    string            // Command path, subcommand names separated by spaces
+	Flags   map[string]string // Flag values by main flag name
+	Args    []string          // Positional arguments in their original order
+	Help    bool              // Help was requested instead of an execution
+}
+
+// cliMu serializes the runs of command trees: cli.Command.Run writes package
+// state of urfave/cli on every call, such as the shared HelpFlag
+var cliMu sync.Mutex
+
+// argv returns the arguments given to the command tree by bind: the command
+// path followed by the positional arguments, verbatim
+func (l commandLine) argv() []string {
+	return append(strings.Fields(l.Path), l.Args...)
+}
+
+// bind sets the line on a fresh command tree and returns a function running
+// the action of its command. The command takes its positional arguments
+// verbatim and its flags from the parsed values, so an argument starting with
+// "-" given after "--" is not parsed again as a flag. The tree is run with the
+// action replaced, under cliMu; the action itself runs outside of it, so
+// concurrent executions only share the package state while binding.
+func (l commandLine) bind(ctx context.Context, root *cli.Command) (func() error, error) {
+	cmd, _ := findCommand(root, strings.Fields(l.Path))
+	action := cmd.Action
+	if action == nil {
+		return nil, ErrNoResult
+	}
+	cmd.SkipFlagParsing = true
+
+	before := cmd.Before
+	cmd.Before = func(ctx context.Context, cmd *cli.Command) (context.Context, error) {
+		for name, value := range l.Flags {
+			if err := cmd.Set(name, value); err != nil {
+				return ctx, fmt.Errorf("%w: --%s: %v", ErrInvalidFlag, name, err)
+			}
+		}
+		if before != nil {
+			return before(ctx, cmd)
+		}
+		return ctx, nil
+	}
+
+	var run func() error
+	cmd.Action = func(ctx context.Context, cmd *cli.Command) error {
+		run = func() error { return action(ctx, cmd) }
+		return nil
+	}
+
+	// Parse errors are returned, the tree must neither print nor exit the server
+	root.Writer = io.Discard
+	root.ErrWriter = io.Discard
+	root.ExitErrHandler = func(context.Context, *cli.Command, error) {}
+
+	cliMu.Lock()
+	err := root.Run(ctx, append([]string{root.Name}, l.argv()...))
+	cliMu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	if run == nil {
+		return nil, ErrNoResult
+	}
+	return run, nil
+}
+
+// parseCommandLine parses a request given either as a raw command line with
+// shell-style quoting, or as a command path with structured Args and Flags.
+// Flags are checked against the command definition, flags written in Args are
+// moved to the flag map so both forms reach the command the same way.
+func (s *CommandStore) parseCommandLine(req models.CommandRequest) (commandLine, error) {
+	line := commandLine{Path: req.CommandPath, Flags: make(map[string]string)}
+	tokens := req.Args
+
+	if req.RawCommand != "" {
+		if len(req.Args) > 0 || len(req.Flags) > 0 {
+			return line, fmt.Errorf("%w: rawCommand cannot be combined with args or flags", ErrInvalidRequest)
+		}
+
+		words, err := shlex.Split(req.RawCommand)
+		if err != nil {
+			return line, fmt.Errorf("%w: %v", ErrInvalidRequest, err)
+		}
+		if len(words) == 0 {
+			return line, fmt.Errorf("%w: empty command line", ErrInvalidRequest)
+		}
+
+		// Without a command path the first word names the command, subcommands
+		// are taken from the following words below
+		if line.Path == "" {
+			line.Path, tokens = words[0], words[1:]
+		} else {
+			path := strings.Fields(line.Path)
+			if len(words) < len(path) || !slices.Equal(words[:len(path)], path) {
+				return line, fmt.Errorf("%w: rawCommand %q does not start with commandPath %q", ErrInvalidRequest, req.RawCommand, line.Path)
+			}
+			tokens = words[len(path):]
+		}
+	}
+
+	root := s.commandTree(req.NodeType)
+	if root == nil {
+		return line, s.errUnregisteredType(req.NodeType)
+	}
+
+	path := strings.Fields(line.Path)
+	cmd, depth := findCommand(root, path)
+	if depth < len(path) || len(path) == 0 {
+		return line, fmt.Errorf("%w %q for %s, valid commands: %s", ErrUnknownCommand, line.Path, req.NodeType, commandNames(cmd))
+	}
+
+	// Leading arguments naming subcommands extend the path, e.g. "session" with
+	// the arguments "create --dn ims" runs "session create"
+	for len(cmd.Commands) > 0 && len(tokens) > 0 {
+		sub := cmd.Command(tokens[0])
+		if sub == nil {
+			break
+		}
+		cmd, path, tokens = sub, append(path, tokens[0]), tokens[1:]
+	}
+	line.Command = cmd
+	line.Path = strings.Join(path, " ")
+
+	for name, value := range req.Flags {
+		flag := lookupFlag(cmd, name)
+		if flag == nil {
+			return line, unknownFlag(req.NodeType, line.Path, cmd, name)
+		}
+		if err := line.setFlag(req.NodeType, flag, value); err != nil {
+			return line, err
+		}
+	}
+
+	for i := 0; i < len(tokens); i++ {
+		token := tokens[i]
+
+		if token == "--" {
+			line.Args = append(line.Args, tokens[i+1:]...)
+			break
+		}
+		if token == "--help" || token == "-h" {
+			line.Help = true
+			continue
+		}
+		if !isFlagToken(token) {
+			line.Args = append(line.Args, token)
+			continue
+		}
+
+		name, value, hasValue := strings.Cut(strings.TrimLeft(token, "-"), "=")
+		flag := lookupFlag(cmd, name)
+		if flag == nil {
+			return line, unknownFlag(req.NodeType, line.Path, cmd, name)
+		}
+
+		if _, isBool := flag.(*cli.BoolFlag); isBool {
+			if !hasValue {
+				value = "true"
+			}
+		} else if !hasValue {
+			if i+1 >= len(tokens) {
+				return line, fmt.Errorf("%w: --%s of %s %s needs a value", ErrInvalidFlag, name, req.NodeType, line.Path)
+			}
+			i++
+			value = tokens[i]
+		}
+		if err := line.setFlag(req.NodeType, flag, value); err != nil {
+			return line, err
+		}
+	}
+
+	// Groups only run through their subcommands
+	if cmd.Action == nil && len(cmd.Commands) > 0 && !line.Help {
+		if len(line.Args) > 0 {
+			return line, fmt.Errorf("%w %q for %s, valid subcommands: %s", ErrUnknownCommand, line.Path+" "+line.Args[0], req.NodeType, commandNames(cmd))
+		}
+		return line, fmt.Errorf("%w %q for %s, valid subcommands: %s", ErrIncompleteCommand, line.Path, req.NodeType, commandNames(cmd))
+	}
+
+	return line, nil
+}
+
+// setFlag records a flag value under the main name of the flag
+func (l *commandLine) setFlag(nodeType string, flag cli.Flag, value string) error {
+	name := flag.Names()[0]
+	if _, isBool := flag.(*cli.BoolFlag); isBool {
+		if _, err := strconv.ParseBool(value); err != nil {
+			return fmt.Errorf("%w: --%s of %s %s expects true or false, got %q", ErrInvalidFlag, name, nodeType, l.Path, value)
+		}
+	}
+	if _, given := l.Flags[name]; given {
+		return fmt.Errorf("%w: --%s of %s %s is given more than once", ErrInvalidFlag, name, nodeType, l.Path)
+	}
+	l.Flags[name] = value
+	return nil
+}
+
+// lookupFlag finds a flag of a command by any of its names
+func lookupFlag(cmd *cli.Command, name string) cli.Flag {
+	for _, flag := range cmd.Flags {
+		for _, n := range flag.Names() {
+			if n == name {
+				return flag
+			}
+		}
+	}
+	return nil
+}
+
+// unknownFlag builds the error of a flag the command does not define
+func unknownFlag(nodeType, path string, cmd *cli.Command, name string) error {
+	if len(cmd.Flags) == 0 {
+		return fmt.Errorf("%w --%s for %s %s, it takes no flags", ErrUnknownFlag, name, nodeType, path)
+	}
+
+	names := make([]string, 0, len(cmd.Flags))
+	for _, flag := range cmd.Flags {
+		names = append(names, "--"+flag.Names()[0])
+	}
+	return fmt.Errorf("%w --%s for %s %s, valid flags: %s", ErrUnknownFlag, name, nodeType, path, strings.Join(names, ", "))
+}
+
+// findCommand walks a command tree along the words of a command path, it
+// returns the deepest command found and how many words named it
+func findCommand(root *cli.Command, path []string) (*cli.Command, int) {
+	cmd := root
+	for i, name := range path {
+		sub := cmd.Command(name)
+		if sub == nil {
+			return cmd, i
+		}
+		cmd = sub
+	}
+	return cmd, len(path)
+}
+
+// commandNames lists the commands of a tree for error messages
+func commandNames(root *cli.Command) string {
+	names := make([]string, 0, len(root.Commands))
+	for _, cmd := range root.Commands {
+		names = append(names, cmd.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// isFlagToken reports whether a token names a flag; negative numbers are arguments
+func isFlagToken(token string) bool {
+	if len(token) < 2 || token[0] != '-' {
+		return false
+	}
+	_, err := strconv.ParseFloat(token, 64)
+	return err != nil
+}
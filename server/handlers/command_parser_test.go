@@ -0,0 +1,337 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/TutuanHo03/remote-control/models"
+
+	"github.com/urfave/cli/v3"
+)
+
+func TestParseCommandLine(t *testing.T) {
+	emu := newFakeEmulator()
+	store := NewCommandStore(emu, emu, emu)
+
+	raw := func(nodeType, command string) models.CommandRequest {
+		return models.CommandRequest{NodeType: nodeType, RawCommand: command}
+	}
+
+	tests := []struct {
+		name  string
+		req   models.CommandRequest
+		path  string
+		flags map[string]string
+		args  []string
+		help  bool
+		err   error
+	}{
+		{
+			name:  "raw bool flag",
+			req:   raw("ue", "register --emergency"),
+			path:  "register",
+			flags: map[string]string{"emergency": "true"},
+		},
+		{
+			name:  "raw bool flag with a value",
+			req:   raw("ue", "register --emergency=false"),
+			path:  "register",
+			flags: map[string]string{"emergency": "false"},
+		},
+		{
+			name:  "raw flag value forms and quoting",
+			req:   raw("ue", `create-session --dn "my dn" --slice='slice 1' --type=2`),
+			path:  "create-session",
+			flags: map[string]string{"dn": "my dn", "slice": "slice 1", "type": "2"},
+		},
+		{
+			name:  "raw command with its path",
+			req:   models.CommandRequest{NodeType: "ue", CommandPath: "create-session", RawCommand: "create-session --dn ims"},
+			path:  "create-session",
+			flags: map[string]string{"dn": "ims"},
+		},
+		{
+			name:  "structured flags",
+			req:   models.CommandRequest{NodeType: "ue", CommandPath: "create-session", Flags: map[string]string{"dn": "ims", "type": "1"}},
+			path:  "create-session",
+			flags: map[string]string{"dn": "ims", "type": "1"},
+		},
+		{
+			name:  "flags written in structured args",
+			req:   models.CommandRequest{NodeType: "ue", CommandPath: "create-session", Args: []string{"--dn", "ims", "--type=1"}},
+			path:  "create-session",
+			flags: map[string]string{"dn": "ims", "type": "1"},
+		},
+		{
+			name:  "structured flags and flags in args",
+			req:   models.CommandRequest{NodeType: "ue", CommandPath: "create-session", Args: []string{"--dn", "ims"}, Flags: map[string]string{"slice": "embb"}},
+			path:  "create-session",
+			flags: map[string]string{"dn": "ims", "slice": "embb"},
+		},
+		{
+			name:  "raw subcommand",
+			req:   raw("ue", "session create --dn ims"),
+			path:  "session create",
+			flags: map[string]string{"dn": "ims"},
+		},
+		{
+			name:  "args naming a subcommand extend the path",
+			req:   models.CommandRequest{NodeType: "ue", CommandPath: "session", Args: []string{"create", "--dn", "ims"}},
+			path:  "session create",
+			flags: map[string]string{"dn": "ims"},
+		},
+		{
+			name: "structured subcommand path",
+			req:  models.CommandRequest{NodeType: "ue", CommandPath: "session list"},
+			path: "session list",
+		},
+		{
+			name: "positional argument",
+			req:  raw("gnb", "release-ue 42"),
+			path: "release-ue",
+			args: []string{"42"},
+		},
+		{
+			name: "negative number argument",
+			req:  raw("gnb", "release-ue -5"),
+			path: "release-ue",
+			args: []string{"-5"},
+		},
+		{
+			name: "arguments after a double dash",
+			req:  raw("gnb", "release-ue -- --odd-id"),
+			path: "release-ue",
+			args: []string{"--odd-id"},
+		},
+		{
+			name: "help",
+			req:  raw("ue", "session create --help"),
+			path: "session create",
+			help: true,
+		},
+		{
+			name: "help of a group",
+			req:  raw("ue", "session -h"),
+			path: "session",
+			help: true,
+		},
+		{
+			name: "unknown raw flag",
+			req:  raw("ue", "register --urgent"),
+			err:  ErrUnknownFlag,
+		},
+		{
+			name: "unknown structured flag",
+			req:  models.CommandRequest{NodeType: "ue", CommandPath: "register", Flags: map[string]string{"urgent": "true"}},
+			err:  ErrUnknownFlag,
+		},
+		{
+			name: "flag of a command without flags",
+			req:  raw("gnb", "release-ue 42 --force"),
+			err:  ErrUnknownFlag,
+		},
+		{
+			name: "missing flag value",
+			req:  raw("ue", "create-session --dn"),
+			err:  ErrInvalidFlag,
+		},
+		{
+			name: "bad bool value",
+			req:  raw("ue", "register --emergency=maybe"),
+			err:  ErrInvalidFlag,
+		},
+		{
+			name: "flag given twice",
+			req:  models.CommandRequest{NodeType: "ue", CommandPath: "create-session", Args: []string{"--dn=ims"}, Flags: map[string]string{"dn": "internet"}},
+			err:  ErrInvalidFlag,
+		},
+		{
+			name: "unknown command",
+			req:  raw("ue", "reboot"),
+			err:  ErrUnknownCommand,
+		},
+		{
+			name: "unknown subcommand",
+			req:  raw("ue", "session destroy"),
+			err:  ErrUnknownCommand,
+		},
+		{
+			name: "group without subcommand",
+			req:  raw("ue", "session"),
+			err:  ErrIncompleteCommand,
+		},
+		{
+			name: "raw command with structured args",
+			req:  models.CommandRequest{NodeType: "ue", RawCommand: "register", Args: []string{"--emergency"}},
+			err:  ErrInvalidRequest,
+		},
+		{
+			name: "raw command not starting with its path",
+			req:  models.CommandRequest{NodeType: "ue", CommandPath: "register", RawCommand: "deregister"},
+			err:  ErrInvalidRequest,
+		},
+		{
+			name: "unterminated quote",
+			req:  raw("ue", `create-session --dn "ims`),
+			err:  ErrInvalidRequest,
+		},
+		{
+			name: "blank raw command",
+			req:  raw("ue", "   "),
+			err:  ErrInvalidRequest,
+		},
+		{
+			name: "unregistered node type",
+			req:  raw("amf", "status"),
+			err:  ErrInvalidNodeType,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			line, err := store.parseCommandLine(tc.req)
+			if tc.err != nil {
+				if !errors.Is(err, tc.err) {
+					t.Fatalf("got error %v, want %v", err, tc.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if line.Path != tc.path {
+				t.Errorf("got path %q, want %q", line.Path, tc.path)
+			}
+			if tc.flags == nil {
+				tc.flags = map[string]string{}
+			}
+			if !maps.Equal(line.Flags, tc.flags) {
+				t.Errorf("got flags %v, want %v", line.Flags, tc.flags)
+			}
+			if !slices.Equal(line.Args, tc.args) {
+				t.Errorf("got args %q, want %q", line.Args, tc.args)
+			}
+			if line.Help != tc.help {
+				t.Errorf("got help %t, want %t", line.Help, tc.help)
+			}
+		})
+	}
+}
+
+func TestCommandLineBind(t *testing.T) {
+	var gotArgs []string
+	var gotDn string
+	var gotCount int64
+	tree := func() *cli.Command {
+		return &cli.Command{
+			Name: "test",
+			Commands: []*cli.Command{
+				{
+					Name: "group",
+					Commands: []*cli.Command{
+						{
+							Name: "echo",
+							Flags: []cli.Flag{
+								&cli.StringFlag{Name: "dn", Aliases: []string{"d"}, Value: "internet"},
+								&cli.IntFlag{Name: "count"},
+							},
+							Action: func(ctx context.Context, cmd *cli.Command) error {
+								gotArgs, gotDn, gotCount = cmd.Args().Slice(), cmd.String("dn"), cmd.Int("count")
+								return nil
+							},
+						},
+					},
+				},
+				{Name: "noop"},
+			},
+		}
+	}
+
+	tests := []struct {
+		name  string
+		line  commandLine
+		args  []string
+		dn    string
+		count int64
+		err   error
+	}{
+		{
+			name:  "flags and arguments",
+			line:  commandLine{Path: "group echo", Flags: map[string]string{"dn": "ims", "count": "3"}, Args: []string{"a", "b"}},
+			args:  []string{"a", "b"},
+			dn:    "ims",
+			count: 3,
+		},
+		{
+			name: "flag defaults",
+			line: commandLine{Path: "group echo", Flags: map[string]string{}},
+			args: []string{},
+			dn:   "internet",
+		},
+		{
+			name: "arguments looking like flags are verbatim",
+			line: commandLine{Path: "group echo", Flags: map[string]string{}, Args: []string{"--dn", "-1", "--"}},
+			args: []string{"--dn", "-1", "--"},
+			dn:   "internet",
+		},
+		{
+			name: "bad flag value",
+			line: commandLine{Path: "group echo", Flags: map[string]string{"count": "many"}},
+			err:  ErrInvalidFlag,
+		},
+		{
+			name: "command without action",
+			line: commandLine{Path: "noop", Flags: map[string]string{}},
+			err:  ErrNoResult,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			gotArgs, gotDn, gotCount = nil, "", 0
+
+			run, err := tc.line.bind(context.Background(), tree())
+			if tc.err != nil {
+				if !errors.Is(err, tc.err) {
+					t.Fatalf("got error %v, want %v", err, tc.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if gotArgs != nil {
+				t.Fatal("action ran while binding")
+			}
+			if err := run(); err != nil {
+				t.Fatal(err)
+			}
+
+			if !slices.Equal(gotArgs, tc.args) || gotDn != tc.dn || gotCount != tc.count {
+				t.Errorf("got args %q, dn %q and count %d, want %q, %q and %d", gotArgs, gotDn, gotCount, tc.args, tc.dn, tc.count)
+			}
+		})
+	}
+}
+
+func TestIsFlagToken(t *testing.T) {
+	for token, want := range map[string]bool{
+		"--dn":      true,
+		"--dn=ims":  true,
+		"-h":        true,
+		"-":         false,
+		"-5":        false,
+		"-1.5":      false,
+		"-1e3":      false,
+		"imsi-0001": false,
+		"":          false,
+	} {
+		if got := isFlagToken(token); got != want {
+			t.Errorf("%q: got %t, want %t", token, got, want)
+		}
+	}
+}
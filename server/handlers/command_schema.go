@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/TutuanHo03/remote-control/models"
+
+	"github.com/urfave/cli/v3"
+)
+
+// SchemaKey is the cli.Command Metadata key holding the CommandSchema of a command
+const SchemaKey = "schema"
+
+// CommandSchema - Validation rules of a command, published in its CommandInfo
+// and checked before the command runs
+type CommandSchema struct {
+	Args  []models.ArgInfo             // Positional arguments in order, nil accepts any
+	Flags map[string]models.ValueRules // Value rules by main flag name
+}
+
+// withSchema returns the command Metadata holding a schema
+func withSchema(schema CommandSchema) map[string]interface{} {
+	return map[string]interface{}{SchemaKey: schema}
+}
+
+// commandSchema returns the schema of a command, empty when it has none
+func commandSchema(cmd *cli.Command) CommandSchema {
+	schema, _ := cmd.Metadata[SchemaKey].(CommandSchema)
+	return schema
+}
+
+// compilePatterns compiles the value patterns of the schemas of a command
+// tree, by pattern, so requests are checked without compiling them again
+func compilePatterns(cmd *cli.Command, patterns map[string]*regexp.Regexp) error {
+	schema := commandSchema(cmd)
+	rules := make([]models.ValueRules, 0, len(schema.Flags)+len(schema.Args))
+	for _, flagRules := range schema.Flags {
+		rules = append(rules, flagRules)
+	}
+	for _, arg := range schema.Args {
+		rules = append(rules, arg.ValueRules)
+	}
+
+	for _, r := range rules {
+		if r.Pattern == "" || patterns[r.Pattern] != nil {
+			continue
+		}
+		re, err := regexp.Compile(r.Pattern)
+		if err != nil {
+			return fmt.Errorf("%s: invalid pattern %q: %v", cmd.Name, r.Pattern, err)
+		}
+		patterns[r.Pattern] = re
+	}
+
+	for _, sub := range cmd.Commands {
+		if err := compilePatterns(sub, patterns); err != nil {
+			return fmt.Errorf("%s %w", cmd.Name, err)
+		}
+	}
+	return nil
+}
+
+// intRange returns the rules of an integer between min and max
+func intRange(min, max int64) models.ValueRules {
+	return models.ValueRules{Min: &min, Max: &max}
+}
+
+// ValidationError is returned for requests whose flags or arguments break the
+// command schema, with one entry per invalid field
+type ValidationError struct {
+	NodeType string
+	Command  string
+	Errors   []models.FieldError
+}
+
+func (e *ValidationError) Error() string {
+	fields := make([]string, 0, len(e.Errors))
+	for _, fe := range e.Errors {
+		fields = append(fields, fe.Field+" "+fe.Message)
+	}
+	return fmt.Sprintf("%s: %s", e.summary(), strings.Join(fields, "; "))
+}
+
+// summary describes the failed request without the field details
+func (e *ValidationError) summary() string {
+	return fmt.Sprintf("invalid request for %s %s", e.NodeType, e.Command)
+}
+
+// validateCommandLine checks a parsed request against the flags and schema of
+// its command, with the patterns compiled when its node type was registered
+func validateCommandLine(nodeType string, line commandLine, patterns map[string]*regexp.Regexp) error {
+	cmd := line.Command
+	schema := commandSchema(cmd)
+	var errs []models.FieldError
+
+	for _, flag := range cmd.Flags {
+		name := flag.Names()[0]
+		field := "--" + name
+
+		value, given := line.Flags[name]
+		if !given {
+			if req, ok := flag.(cli.RequiredFlag); ok && req.IsRequired() {
+				errs = append(errs, models.FieldError{Field: field, Message: "is required"})
+			}
+			continue
+		}
+
+		// Integer flags are checked in the base the flag parses them with
+		base := 10
+		intFlag, isInt := flag.(*cli.IntFlag)
+		if isInt {
+			base = intFlag.Config.Base
+		}
+		if msg := checkValue(value, isInt, base, schema.Flags[name], patterns); msg != "" {
+			errs = append(errs, models.FieldError{Field: field, Message: msg})
+		}
+	}
+
+	if schema.Args != nil {
+		for i, arg := range schema.Args {
+			if i >= len(line.Args) {
+				if arg.Required {
+					errs = append(errs, models.FieldError{Field: arg.Name, Message: "is required"})
+				}
+				continue
+			}
+			// Actions parse integer arguments in base 10, e.g. with strconv.Atoi
+			if msg := checkValue(line.Args[i], arg.Type == models.FlagTypeInt, 10, arg.ValueRules, patterns); msg != "" {
+				errs = append(errs, models.FieldError{Field: arg.Name, Message: msg})
+			}
+		}
+		for _, extra := range line.Args[min(len(schema.Args), len(line.Args)):] {
+			errs = append(errs, models.FieldError{Field: "args", Message: fmt.Sprintf("unexpected argument %q", extra)})
+		}
+	}
+
+	if len(errs) > 0 {
+		return &ValidationError{NodeType: nodeType, Command: line.Path, Errors: errs}
+	}
+	return nil
+}
+
+// checkValue checks a value against its rules and returns what is wrong with
+// it; integers are parsed in base, 0 accepting the Go prefixes as cli.IntFlag
+func checkValue(value string, isInt bool, base int, rules models.ValueRules, patterns map[string]*regexp.Regexp) string {
+	if isInt || rules.Min != nil || rules.Max != nil {
+		n, err := strconv.ParseInt(value, base, 64)
+		if err != nil {
+			return fmt.Sprintf("must be an integer, got %q", value)
+		}
+		switch {
+		case rules.Min != nil && rules.Max != nil && (n < *rules.Min || n > *rules.Max):
+			return fmt.Sprintf("must be between %d and %d, got %d", *rules.Min, *rules.Max, n)
+		case rules.Min != nil && n < *rules.Min:
+			return fmt.Sprintf("must be at least %d, got %d", *rules.Min, n)
+		case rules.Max != nil && n > *rules.Max:
+			return fmt.Sprintf("must be at most %d, got %d", *rules.Max, n)
+		}
+	}
+
+	if len(rules.Enum) > 0 && !slices.Contains(rules.Enum, value) {
+		return fmt.Sprintf("must be one of %s, got %q", strings.Join(rules.Enum, ", "), value)
+	}
+
+	if rules.Pattern != "" {
+		// Only a tree built differently than at registration misses its pattern
+		re := patterns[rules.Pattern]
+		if re == nil {
+			return fmt.Sprintf("cannot be checked, unknown pattern %q", rules.Pattern)
+		}
+		if !re.MatchString(value) {
+			return fmt.Sprintf("must match %s, got %q", rules.Pattern, value)
+		}
+	}
+
+	return ""
+}
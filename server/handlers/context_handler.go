@@ -1,9 +1,14 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
+	"log"
 	"net/http"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/TutuanHo03/remote-control/models"
 
@@ -33,16 +38,27 @@ type Context struct {
 
 // ContextHandler - Manages context navigation and command execution
 type ContextHandler struct {
+	mu           sync.RWMutex        // Guards contextMap and the Children of every context
 	rootContext  *Context            // Root context of the system
 	contextMap   map[string]*Context // Map to store all contexts by path
 	commandStore *CommandStore       // Reference to command definitions
+	sessions     *SessionManager     // Navigation state of connected operators
+	events       *EventBroker        // Receives node added and removed events
+	syncMu       sync.Mutex          // Serializes node syncs, from the snapshot of the nodes to the pruning
+	syncRequests chan struct{}       // Node sync requested by the emulator, pending at most once
+	stopSync     chan struct{}       // Stops the node sync loop
+	stopOnce     sync.Once
 }
 
 // NewContextHandler creates a new context handler with initialized contexts
-func NewContextHandler(commandStore *CommandStore) *ContextHandler {
+func NewContextHandler(commandStore *CommandStore, sessions *SessionManager, events *EventBroker) *ContextHandler {
 	handler := &ContextHandler{
 		contextMap:   make(map[string]*Context),
 		commandStore: commandStore,
+		sessions:     sessions,
+		events:       events,
+		syncRequests: make(chan struct{}, 1),
+		stopSync:     make(chan struct{}),
 	}
 
 	// Initialize the context hierarchy
@@ -75,34 +91,43 @@ func (h *ContextHandler) initializeContexts() {
 	h.rootContext.Children["server"] = serverContext
 	h.contextMap["server"] = serverContext
 
-	// Context sets for different node types
-	nodeTypes := []string{"ue", "gnb", "emulator"}
-	for _, nodeType := range nodeTypes {
+	h.addContextSets()
+}
+
+// addContextSets creates the context sets of node types registered since the
+// last call; h.mu must be held
+func (h *ContextHandler) addContextSets() {
+	serverContext := h.contextMap["server"]
+	for _, spec := range h.commandStore.NodeTypes() {
+		if _, exists := serverContext.Children[spec.Name]; exists {
+			continue
+		}
+
 		contextSet := &Context{
 			Type:        ContextSetType,
-			Name:        nodeType,
-			Description: strings.ToUpper(nodeType) + " context set",
+			Name:        spec.Name,
+			Description: spec.Description,
 			Parent:      serverContext,
 			Commands:    h.getContextSetCommands(),
 			Children:    make(map[string]*Context),
-			NodeType:    nodeType,
+			NodeType:    spec.Name,
 		}
-		serverContext.Children[nodeType] = contextSet
-		h.contextMap[nodeType] = contextSet
+		serverContext.Children[spec.Name] = contextSet
+		h.contextMap[spec.Name] = contextSet
 
-		// For emulator, create a direct node context automatically
-		if nodeType == "emulator" {
-			emulatorNode := &Context{
+		// Singleton types have their only node created right away
+		if spec.Singleton {
+			node := &Context{
 				Type:        NodeType,
-				Name:        "emulator",
-				Description: "Emulator control context",
+				Name:        spec.Name,
+				Description: spec.Description,
 				Parent:      contextSet,
-				Commands:    h.commandStore.GetCommandsForNodeType("emulator"),
+				Commands:    h.commandStore.GetCommandsForNodeType(spec.Name),
 				Children:    make(map[string]*Context),
-				NodeType:    "emulator",
+				NodeType:    spec.Name,
 			}
-			contextSet.Children["emulator"] = emulatorNode
-			h.contextMap["emulator:emulator"] = emulatorNode
+			contextSet.Children[spec.Name] = node
+			h.contextMap[spec.Name+":"+spec.Name] = node
 		}
 	}
 }
@@ -151,7 +176,7 @@ func (h *ContextHandler) getBasicCommands(includeHelp bool, includeBack bool, in
 	if includeDisconnect {
 		commands = append(commands, models.CommandInfo{
 			Name:        "use",
-			Usage:       "Select a context to use [use emulator | ue | gnb]",
+			Usage:       "Select a context to use [use " + strings.Join(h.commandStore.nodeTypeNames(), " | ") + "]",
 			Description: "Navigate to a specific context type",
 			ArgsUsage:   "<context-type>",
 		})
@@ -177,17 +202,32 @@ func (h *ContextHandler) getContextSetCommands() []models.CommandInfo {
 
 // FindOrCreateNodeContext finds an existing node context or creates one if it doesn't exist
 func (h *ContextHandler) FindOrCreateNodeContext(nodeType string, nodeName string) *Context {
+	ctx, _ := h.findOrCreateNodeContext(nodeType, nodeName)
+	return ctx
+}
+
+// findOrCreateNodeContext is FindOrCreateNodeContext also reporting whether
+// the context was created
+func (h *ContextHandler) findOrCreateNodeContext(nodeType string, nodeName string) (*Context, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.findOrCreateNodeContextLocked(nodeType, nodeName)
+}
+
+// findOrCreateNodeContextLocked is findOrCreateNodeContext for callers
+// holding h.mu
+func (h *ContextHandler) findOrCreateNodeContextLocked(nodeType string, nodeName string) (*Context, bool) {
 	contextKey := nodeType + ":" + nodeName
 
 	// Check if context already exists
 	if ctx, exists := h.contextMap[contextKey]; exists {
-		return ctx
+		return ctx, false
 	}
 
 	// Find parent context
 	parentCtx, exists := h.contextMap[nodeType]
 	if !exists {
-		return nil
+		return nil, false
 	}
 
 	// Create new node context
@@ -204,7 +244,120 @@ func (h *ContextHandler) FindOrCreateNodeContext(nodeType string, nodeName strin
 	h.contextMap[contextKey] = nodeContext
 	parentCtx.Children[nodeName] = nodeContext
 
-	return nodeContext
+	return nodeContext, true
+}
+
+// SyncNodes reconciles the node contexts of every context set with the nodes
+// currently reported by the emulator and moves sessions out of removed nodes.
+// Syncs run one at a time, so a sync never prunes a node added by a later one.
+func (h *ContextHandler) SyncNodes() {
+	h.syncMu.Lock()
+	defer h.syncMu.Unlock()
+
+	h.mu.Lock()
+	h.addContextSets()
+	contextSets := make([]*Context, 0)
+	for _, spec := range h.commandStore.NodeTypes() {
+		if !spec.Singleton {
+			contextSets = append(contextSets, h.contextMap[spec.Name])
+		}
+	}
+	h.mu.Unlock()
+
+	for _, contextSet := range contextSets {
+		// The nodes are listed, created and pruned under one lock: a node
+		// context created by a select in between would be pruned otherwise
+		h.mu.Lock()
+		objects, err := h.commandStore.GetObjectsOfType(contextSet.NodeType)
+		if err != nil {
+			h.mu.Unlock()
+			log.Printf("Failed to sync %s nodes: %v", contextSet.NodeType, err)
+			continue
+		}
+
+		live := make(map[string]bool, len(objects))
+		var added []string
+		for _, name := range objects {
+			live[name] = true
+			if _, created := h.findOrCreateNodeContextLocked(contextSet.NodeType, name); created {
+				added = append(added, name)
+			}
+		}
+
+		var removed []*Context
+		for name, nodeCtx := range contextSet.Children {
+			if !live[name] {
+				delete(contextSet.Children, name)
+				delete(h.contextMap, contextSet.NodeType+":"+name)
+				removed = append(removed, nodeCtx)
+			}
+		}
+		h.mu.Unlock()
+
+		// Sessions and subscribers are notified outside of the lock
+		for _, name := range added {
+			h.events.Publish(models.NodeEvent{
+				Type:     models.EventNodeAdded,
+				NodeType: contextSet.NodeType,
+				NodeName: name,
+			})
+		}
+		for _, nodeCtx := range removed {
+			h.sessions.NodeRemoved(nodeCtx)
+			h.events.Publish(models.NodeEvent{
+				Type:     models.EventNodeRemoved,
+				NodeType: nodeCtx.NodeType,
+				NodeName: nodeCtx.Name,
+			})
+		}
+	}
+}
+
+// requestSync asks the sync loop for a node sync without waiting for it;
+// changes reported while a sync is pending are covered by that sync
+func (h *ContextHandler) requestSync() {
+	select {
+	case h.syncRequests <- struct{}{}:
+	default:
+	}
+}
+
+// StartNodeSync keeps node contexts in sync with the emulator, every interval
+// and whenever nodes are added or removed. The emulator commands sync before
+// they answer, so the nodes they add can be selected right away. Changes
+// reported by an emulator implementing NodeChangeNotifier are synced in the
+// background, so adding many nodes at once does not sync the whole tree
+// after every node.
+func (h *ContextHandler) StartNodeSync(interval time.Duration) {
+	h.commandStore.OnNodesChanged(h.SyncNodes)
+	if notifier, ok := h.commandStore.eApi.(NodeChangeNotifier); ok {
+		notifier.OnNodesChanged(h.requestSync)
+	}
+
+	h.SyncNodes()
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-h.stopSync:
+				return
+			case <-ticker.C:
+				h.SyncNodes()
+			case <-h.syncRequests:
+				h.SyncNodes()
+			}
+		}
+	}()
+}
+
+// Close stops the node sync loop
+func (h *ContextHandler) Close() {
+	h.stopOnce.Do(func() {
+		close(h.stopSync)
+	})
 }
 
 // NavigateContext handles navigation between contexts
@@ -216,44 +369,40 @@ func (h *ContextHandler) NavigateContext(c *gin.Context) {
 		})
 		return
 	}
+	defer h.auditNavigation(c, req, time.Now())
 
-	// Find the current context
-	var currentCtx *Context
-	var exists bool
-
-	// Process node contexts
-	contextKey := req.CurrentContext
-	if req.NodeType != "" && req.CurrentContext != "" {
-		// Chỉ sử dụng prefix cho node contexts
-		if req.NodeType != req.CurrentContext { // Check for the case where they are the same
-			contextKey = req.NodeType + ":" + req.CurrentContext
-		}
-	}
-
-	// FInd context
-	if req.CurrentContext != "" && req.CurrentContext != "root" {
-		currentCtx, exists = h.contextMap[contextKey]
-
-		if !exists {
-			currentCtx, exists = h.contextMap[req.CurrentContext]
+	// connect starts a new session, every other command works on an existing one
+	if req.Command == "connect" {
+		if len(req.Args) < 1 {
+			c.JSON(http.StatusBadRequest, models.NavigationResponse{
+				Error: "URL is required for connect command",
+			})
+			return
 		}
+		serverURL := req.Args[0]
+		serverCtx, _ := h.lookupContext("server")
+		session := h.sessions.Create(c.Request.Context(), c.ClientIP(), serverURL, h.rootContext, serverCtx)
+		c.Set(sessionIDKey, session.ID)
 
-		if !exists {
-			fmt.Printf("Context not found: %s (tried keys: %s, %s)\n",
-				req.CurrentContext, contextKey, req.CurrentContext)
-			fmt.Printf("Available contexts: %v\n", h.getContextKeys())
-		}
-	} else if req.CurrentContext == "root" {
-		currentCtx = h.rootContext
-		exists = true
+		h.respondNavigation(c, session, serverCtx,
+			fmt.Sprintf("Connected to server: %s, type help to see commands", serverURL), nil)
+		return
 	}
 
-	if !exists && req.CurrentContext != "root" {
-		c.JSON(http.StatusBadRequest, models.NavigationResponse{
-			Error: fmt.Sprintf("Current context not found: %s", req.CurrentContext),
+	session, err := h.sessions.Access(c.Request.Context(), req.SessionID)
+	if errors.Is(err, ErrSessionNotOwned) {
+		c.JSON(http.StatusForbidden, models.NavigationResponse{
+			Error: "The session belongs to another user",
 		})
 		return
 	}
+	if err != nil {
+		c.JSON(http.StatusGone, models.NavigationResponse{
+			Error: "Session not found or expired, please connect again",
+		})
+		return
+	}
+	currentCtx := session.Current()
 
 	var newCtx *Context
 	var message string
@@ -261,35 +410,25 @@ func (h *ContextHandler) NavigateContext(c *gin.Context) {
 
 	// Process navigation command
 	switch req.Command {
-	case "connect":
-		if len(req.Args) < 1 {
-			c.JSON(http.StatusBadRequest, models.NavigationResponse{
-				Error: "URL is required for connect command",
-			})
-			return
-		}
-		serverURL := req.Args[0]
-		newCtx = h.contextMap["server"]
-		message = fmt.Sprintf("Connected to server: %s, type help to see commands", serverURL)
-
 	case "disconnect":
-		newCtx = h.rootContext
-		message = "Disconnected from server"
+		h.sessions.Remove(session.ID)
+		h.respondNavigation(c, nil, h.rootContext, "Disconnected from server", nil)
+		return
 
 	case "back":
-		if currentCtx != nil && currentCtx.Parent != nil {
-			newCtx = currentCtx.Parent
-			if newCtx.Type == ServerType {
-				message = "Back to server context"
-			} else {
-				message = fmt.Sprintf("Back to %s context", newCtx.Name)
-			}
-		} else {
+		parentCtx, ok := session.Pop()
+		if !ok {
 			c.JSON(http.StatusBadRequest, models.NavigationResponse{
 				Error: "Already at root context",
 			})
 			return
 		}
+		newCtx = parentCtx
+		if newCtx.Type == ServerType {
+			message = "Back to server context"
+		} else {
+			message = fmt.Sprintf("Back to %s context", newCtx.Name)
+		}
 
 	case "use":
 		if len(req.Args) < 1 {
@@ -301,23 +440,22 @@ func (h *ContextHandler) NavigateContext(c *gin.Context) {
 		contextType := req.Args[0]
 
 		// Check if context type exists
-		childCtx, exists := h.contextMap[contextType]
+		childCtx, exists := h.lookupContext(contextType)
 		if !exists || childCtx.Type != ContextSetType {
 			c.JSON(http.StatusBadRequest, models.NavigationResponse{
-				Error: "Invalid context type. Use 'emulator', 'ue', or 'gnb'",
+				Error: "Invalid context type. Use one of: " + strings.Join(h.commandStore.nodeTypeNames(), ", "),
 			})
 			return
 		}
 
 		newCtx = childCtx
 
-		// Special handling for emulator
-		if contextType == "emulator" {
-			emulatorNode := h.FindOrCreateNodeContext("emulator", "emulator")
-			newCtx = emulatorNode
-			message = "Switched to emulator context"
+		// Singleton types go straight to their only node
+		if rt, ok := h.commandStore.nodeType(contextType); ok && rt.spec.Singleton {
+			newCtx = h.FindOrCreateNodeContext(contextType, contextType)
+			message = fmt.Sprintf("Switched to %s context", contextType)
 		} else {
-			// For UE/GNB, list available objects
+			// For other types, list available objects
 			objects, err := h.commandStore.GetObjectsOfType(contextType)
 			if err != nil {
 				c.JSON(http.StatusInternalServerError, models.NavigationResponse{
@@ -332,6 +470,10 @@ func (h *ContextHandler) NavigateContext(c *gin.Context) {
 			}
 		}
 
+		// use always starts from the server context
+		session.Unwind(ServerType)
+		session.Push(newCtx)
+
 	case "select":
 		if len(req.Args) < 1 {
 			c.JSON(http.StatusBadRequest, models.NavigationResponse{
@@ -341,7 +483,7 @@ func (h *ContextHandler) NavigateContext(c *gin.Context) {
 		}
 
 		nodeName := req.Args[0]
-		if currentCtx == nil || currentCtx.Type != ContextSetType {
+		if currentCtx.Type != ContextSetType {
 			c.JSON(http.StatusBadRequest, models.NavigationResponse{
 				Error: "Can only select nodes from a context set",
 			})
@@ -374,22 +516,11 @@ func (h *ContextHandler) NavigateContext(c *gin.Context) {
 		}
 
 		// Find or create node context
-		nodeCtx := h.FindOrCreateNodeContext(nodeType, nodeName)
-		newCtx = nodeCtx
+		newCtx = h.FindOrCreateNodeContext(nodeType, nodeName)
 		message = fmt.Sprintf("Selected node: %s", nodeName)
-
 		cmdInfos = h.commandStore.GetCommandsForNodeType(nodeType)
 
-		// Create client context
-		clientContext := h.createClientContext(nodeCtx)
-
-		c.JSON(http.StatusOK, models.NavigationResponse{
-			Context:  clientContext,
-			Prompt:   nodeName + " >>> ",
-			Message:  message,
-			Commands: cmdInfos,
-		})
-		return
+		session.Push(newCtx)
 
 	default:
 		c.JSON(http.StatusBadRequest, models.NavigationResponse{
@@ -398,8 +529,54 @@ func (h *ContextHandler) NavigateContext(c *gin.Context) {
 		return
 	}
 
+	h.respondNavigation(c, session, newCtx, message, cmdInfos)
+}
+
+// auditNavigation records a navigation call once it was answered
+func (h *ContextHandler) auditNavigation(c *gin.Context, req models.NavigationRequest, start time.Time) {
+	audit := h.commandStore.auditLog()
+	if audit == nil {
+		return
+	}
+
+	record := newAuditRecord(c.Request.Context(), models.AuditNavigate, start)
+	record.SessionID = req.SessionID
+	if sessionID := c.GetString(sessionIDKey); sessionID != "" {
+		record.SessionID = sessionID
+	}
+	record.ClientIP = c.ClientIP()
+	record.NodeType = req.NodeType
+	record.CommandPath = req.Command
+	record.Args = req.Args
+	switch {
+	case req.Command == "use" && len(req.Args) > 0:
+		record.NodeType = req.Args[0]
+	case req.Command == "select" && len(req.Args) > 0:
+		record.NodeName = req.Args[0]
+	}
+
+	record.Outcome = models.StatusSuccess
+	if status := c.Writer.Status(); status >= http.StatusBadRequest {
+		record.Outcome = models.StatusError
+		record.Message = fmt.Sprintf("HTTP %d %s", status, http.StatusText(status))
+	}
+
+	audit.Record(record)
+}
+
+// respondNavigation sends the navigation response for the new context
+func (h *ContextHandler) respondNavigation(c *gin.Context, session *Session, newCtx *Context, message string, cmdInfos []models.CommandInfo) {
+	var sessionID string
+	var notices []string
+	if session != nil {
+		sessionID = session.ID
+		notices = session.TakeNotices()
+	}
+
 	// Create client context from server context
+	h.mu.RLock()
 	clientContext := h.createClientContext(newCtx)
+	h.mu.RUnlock()
 
 	// Create appropriate prompt
 	var prompt string
@@ -410,15 +587,28 @@ func (h *ContextHandler) NavigateContext(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, models.NavigationResponse{
-		Context:  clientContext,
-		Prompt:   prompt,
-		Message:  message,
-		Commands: cmdInfos,
+		SessionID: sessionID,
+		Context:   clientContext,
+		Prompt:    prompt,
+		Message:   message,
+		Commands:  cmdInfos,
+		Notices:   notices,
 	})
 }
 
+// lookupContext retrieves a context from the context map by its key
+func (h *ContextHandler) lookupContext(key string) (*Context, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	ctx, exists := h.contextMap[key]
+	return ctx, exists
+}
+
 // findContext retrieves a context from the context map
 func (h *ContextHandler) findContext(path string, nodeType string) (*Context, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
 	if nodeType != "" && path != "" && nodeType != path {
 		contextKey := nodeType + ":" + path
 		if ctx, exists := h.contextMap[contextKey]; exists {
@@ -437,7 +627,8 @@ func (h *ContextHandler) findContext(path string, nodeType string) (*Context, bo
 	return nil, false
 }
 
-// createClientContext converts a server context to a client context
+// createClientContext converts a server context to a client context,
+// the caller must hold h.mu since the children are listed
 func (h *ContextHandler) createClientContext(ctx *Context) models.ClientContext {
 	if ctx == nil {
 		return models.ClientContext{}
@@ -449,11 +640,25 @@ func (h *ContextHandler) createClientContext(ctx *Context) models.ClientContext
 		commandNames[i] = cmd.Name
 	}
 
+	var parentPath string
+	if ctx.Parent != nil {
+		parentPath = ctx.Parent.Name
+	}
+
+	childrenPaths := make([]string, 0, len(ctx.Children))
+	for name := range ctx.Children {
+		childrenPaths = append(childrenPaths, name)
+	}
+	sort.Strings(childrenPaths)
+
 	return models.ClientContext{
-		Type:     string(ctx.Type),
-		Name:     ctx.Name,
-		NodeType: ctx.NodeType,
-		Commands: commandNames,
+		Type:          string(ctx.Type),
+		Name:          ctx.Name,
+		Description:   ctx.Description,
+		ParentPath:    parentPath,
+		ChildrenPaths: childrenPaths,
+		NodeType:      ctx.NodeType,
+		Commands:      commandNames,
 	}
 }
 
@@ -461,6 +666,9 @@ func (h *ContextHandler) createClientContext(ctx *Context) models.ClientContext
 func (h *ContextHandler) GetContextByPath(c *gin.Context) {
 	path := c.Param("path")
 
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
 	ctx, exists := h.contextMap[path]
 	if !exists {
 		c.JSON(http.StatusNotFound, gin.H{
@@ -471,23 +679,11 @@ func (h *ContextHandler) GetContextByPath(c *gin.Context) {
 
 	clientContext := h.createClientContext(ctx)
 
-	// Get parent path
-	var parentPath string
-	if ctx.Parent != nil {
-		parentPath = ctx.Parent.Name
-	}
-
-	// Get children paths
-	childrenPaths := make([]string, 0, len(ctx.Children))
-	for name := range ctx.Children {
-		childrenPaths = append(childrenPaths, name)
-	}
-
 	c.JSON(http.StatusOK, gin.H{
 		"context":       clientContext,
 		"description":   ctx.Description,
-		"parentPath":    parentPath,
-		"childrenPaths": childrenPaths,
+		"parentPath":    clientContext.ParentPath,
+		"childrenPaths": clientContext.ChildrenPaths,
 	})
 }
 
@@ -512,7 +708,7 @@ func (h *ContextHandler) GetNodeCommands(c *gin.Context) {
 	nodeName := c.Param("name")
 
 	contextKey := nodeType + ":" + nodeName
-	ctx, exists := h.contextMap[contextKey]
+	ctx, exists := h.lookupContext(contextKey)
 
 	if !exists {
 		// Try to get commands without a context
@@ -535,29 +731,30 @@ func (h *ContextHandler) GetNodeCommands(c *gin.Context) {
 func (h *ContextHandler) ExecuteCommand(c *gin.Context) {
 	var req models.CommandRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, models.CommandResponse{
-			Error: "Invalid request format: " + err.Error(),
-		})
+		c.JSON(http.StatusBadRequest, errorResponse(req, models.ErrCodeInvalidRequest,
+			errors.New("Invalid request format: "+err.Error())))
 		return
 	}
 
-	// Execute the command via command store
-	response, err := h.commandStore.ExecuteCommand(req)
+	// Commands sent within a session default to the node the session is in
+	notices, err := h.sessions.ApplyToRequest(c.Request.Context(), &req)
+	if errors.Is(err, ErrSessionNotOwned) {
+		c.JSON(http.StatusForbidden, errorResponse(req, models.ErrCodeForbidden, err))
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.CommandResponse{
-			Error: err.Error(),
-		})
+		c.JSON(http.StatusGone, errorResponse(req, models.ErrCodeSessionExpired, err))
 		return
 	}
 
-	c.JSON(http.StatusOK, response)
-}
-
-// Add helper function to debug
-func (h *ContextHandler) getContextKeys() []string {
-	keys := make([]string, 0, len(h.contextMap))
-	for k := range h.contextMap {
-		keys = append(keys, k)
+	// Execute the command via command store
+	ctx := withClientIP(c.Request.Context(), c.ClientIP())
+	response, err := h.commandStore.ExecuteCommand(ctx, req)
+	status := http.StatusOK
+	if err != nil {
+		status, response = commandErrorResponse(req, err)
 	}
-	return keys
+
+	response.Notices = notices
+	c.JSON(status, response)
 }
@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/TutuanHo03/remote-control/models"
+)
+
+// fakeEmulator is an in-memory emulator whose nodes accept every procedure;
+// the hooks, when set, run before ListUes and AddUe answer
+type fakeEmulator struct {
+	mu      sync.Mutex
+	ues     []string
+	gnbs    []string
+	onList  func()
+	onAddUe func(supi string)
+}
+
+func newFakeEmulator() *fakeEmulator {
+	return &fakeEmulator{
+		ues:  []string{"imsi-001010000000001", "imsi-001010000000002"},
+		gnbs: []string{"gnb1"},
+	}
+}
+
+func (e *fakeEmulator) ListUes() []string {
+	e.mu.Lock()
+	ues := slices.Clone(e.ues)
+	onList := e.onList
+	e.mu.Unlock()
+
+	if onList != nil {
+		onList()
+	}
+	return ues
+}
+
+func (e *fakeEmulator) ListGnbs() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return slices.Clone(e.gnbs)
+}
+
+func (e *fakeEmulator) AddUe(supi string, triggerRegister bool) bool {
+	e.mu.Lock()
+	onAddUe := e.onAddUe
+	added := !slices.Contains(e.ues, supi)
+	if added {
+		e.ues = append(e.ues, supi)
+	}
+	e.mu.Unlock()
+
+	if onAddUe != nil {
+		onAddUe(supi)
+	}
+	return added
+}
+
+func (e *fakeEmulator) GetUe(name string) (UeApi, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if !slices.Contains(e.ues, name) {
+		return nil, ErrNodeNotFound
+	}
+	return fakeNode{}, nil
+}
+
+func (e *fakeEmulator) GetGnb(name string) (GnbApi, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if !slices.Contains(e.gnbs, name) {
+		return nil, ErrNodeNotFound
+	}
+	return fakeNode{}, nil
+}
+
+// fakeNode is a UE or gNB of the fake emulator
+type fakeNode struct{}
+
+func (fakeNode) Register(isEmergency bool) bool                                    { return true }
+func (fakeNode) Deregister(deregisterType uint8) bool                              { return true }
+func (fakeNode) CreateSession(slice string, dnName string, sessionType uint8) bool { return true }
+func (fakeNode) ReleaseUe(ueId string) bool                                        { return true }
+func (fakeNode) ReleaseSession(ueId string, sessionId uint8) bool                  { return true }
+
+// newTestContextHandler returns a context handler over the fake emulator
+func newTestContextHandler(t *testing.T, emu *fakeEmulator) *ContextHandler {
+	t.Helper()
+
+	store := NewCommandStore(emu, emu, emu)
+	sessions := NewSessionManager(time.Minute)
+	events := NewEventBroker(emu)
+	h := NewContextHandler(store, sessions, events)
+	t.Cleanup(func() {
+		h.Close()
+		events.Close()
+		sessions.Close()
+	})
+	return h
+}
+
+func TestSyncNodesSerialized(t *testing.T) {
+	emu := newFakeEmulator()
+	h := newTestContextHandler(t, emu)
+	h.SyncNodes()
+
+	events, unsubscribe := h.events.Subscribe()
+	defer unsubscribe()
+
+	// Hold the first sync right after it listed the UEs
+	listed := make(chan struct{})
+	release := make(chan struct{})
+	emu.onList = func() {
+		emu.mu.Lock()
+		emu.onList = nil
+		emu.mu.Unlock()
+		close(listed)
+		<-release
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		h.SyncNodes()
+	}()
+	<-listed
+
+	supi := "imsi-001010000000003"
+	emu.AddUe(supi, false)
+	go func() {
+		defer wg.Done()
+		h.SyncNodes()
+	}()
+
+	// Give the second sync the time to run ahead if it could
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if _, exists := h.lookupContext("ue:" + supi); !exists {
+		t.Errorf("context of %s pruned while the UE exists", supi)
+	}
+	for {
+		select {
+		case event := <-events:
+			if event.NodeName == supi && event.Type != models.EventNodeAdded {
+				t.Errorf("got %s event for %s, want only %s", event.Type, supi, models.EventNodeAdded)
+			}
+		default:
+			return
+		}
+	}
+}
+
+func TestSyncNodesKeepsSelectedNode(t *testing.T) {
+	emu := newFakeEmulator()
+	h := newTestContextHandler(t, emu)
+	h.SyncNodes()
+
+	// A UE is added and selected right after the sync listed the UEs
+	supi := "imsi-001010000000003"
+	selected := make(chan struct{})
+	emu.onList = func() {
+		emu.mu.Lock()
+		emu.onList = nil
+		emu.mu.Unlock()
+
+		emu.AddUe(supi, false)
+		go func() {
+			h.FindOrCreateNodeContext("ue", supi)
+			close(selected)
+		}()
+		select {
+		case <-selected:
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+	h.SyncNodes()
+	<-selected
+
+	if _, exists := h.lookupContext("ue:" + supi); !exists {
+		t.Errorf("context of the selected %s pruned while the UE exists", supi)
+	}
+}
+
+func TestAddUeSyncsNodes(t *testing.T) {
+	emu := newFakeEmulator()
+	h := newTestContextHandler(t, emu)
+	h.StartNodeSync(time.Hour)
+
+	supi := "imsi-001010000000003"
+	req := models.CommandRequest{NodeType: "emulator", RawCommand: "add-ue " + supi}
+	response, err := h.commandStore.ExecuteCommand(context.Background(), req)
+	if err != nil || !response.Result.Succeeded() {
+		t.Fatalf("add-ue: %v %+v", err, response.Result)
+	}
+
+	// The new UE can be selected as soon as the command answers
+	if _, exists := h.findContext(supi, "ue"); !exists {
+		t.Errorf("no context for %s after add-ue", supi)
+	}
+}
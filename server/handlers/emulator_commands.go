@@ -0,0 +1,280 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/TutuanHo03/remote-control/models"
+
+	"github.com/urfave/cli/v3"
+)
+
+// NodeProvisioner is implemented by emulators that remove UEs and add or
+// remove gNBs at runtime, for the remove-ue, add-gnb and remove-gnb commands
+type NodeProvisioner interface {
+	RemoveUe(supi string) bool
+	AddGnb(name string, triggerNgSetup bool) bool
+	RemoveGnb(name string) bool
+}
+
+// MaxUeRange is the largest number of UEs add-ue-range provisions at once
+const MaxUeRange = 10000
+
+// supiArg is the SUPI argument of the emulator commands
+var supiArg = models.ArgInfo{
+	Name:       "supi",
+	Usage:      "SUPI of the UE, imsi-<15 digits>",
+	Required:   true,
+	ValueRules: models.ValueRules{Pattern: `^imsi-[0-9]{15}$`},
+}
+
+// gnbNameArg is the gNB argument of the emulator commands
+var gnbNameArg = models.ArgInfo{
+	Name:     "name",
+	Usage:    "Name of the gNB",
+	Required: true,
+}
+
+// OnNodesChanged registers a callback run after commands that add or remove
+// nodes, before they answer; each command runs the callbacks once
+func (s *CommandStore) OnNodesChanged(callback func()) {
+	s.nodesMu.Lock()
+	defer s.nodesMu.Unlock()
+	s.nodesChanged = append(s.nodesChanged, callback)
+}
+
+// notifyNodesChanged runs the callbacks registered with OnNodesChanged
+func (s *CommandStore) notifyNodesChanged() {
+	s.nodesMu.Lock()
+	callbacks := append([]func(){}, s.nodesChanged...)
+	s.nodesMu.Unlock()
+
+	for _, callback := range callbacks {
+		callback()
+	}
+}
+
+// provisioner returns the emulator if it adds and removes nodes at runtime
+func (s *CommandStore) provisioner() (NodeProvisioner, bool) {
+	provisioner, ok := s.eApi.(NodeProvisioner)
+	return provisioner, ok
+}
+
+// newRemoveUeCommand builds the command removing a UE from the emulator
+func (s *CommandStore) newRemoveUeCommand() *cli.Command {
+	return &cli.Command{
+		Name:        "remove-ue",
+		Usage:       "Remove a UE",
+		ArgsUsage:   "<supi>",
+		Description: "Remove the UE with the specified SUPI from the emulator",
+		Metadata: withSchema(CommandSchema{
+			Args: []models.ArgInfo{supiArg},
+		}),
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			supi := cmd.Args().First()
+			data := map[string]string{
+				"supi": supi,
+			}
+			provisioner, ok := s.provisioner()
+			if !ok {
+				unsupported(ctx, "The emulator does not support removing UEs")
+				return nil
+			}
+
+			if provisioner.RemoveUe(supi) {
+				s.notifyNodesChanged()
+				Succeed(ctx, fmt.Sprintf("UE %s removed successfully from emulator", supi), data)
+			} else {
+				Fail(ctx, models.ErrCodeOperationFailed, fmt.Sprintf("Failed to remove UE %s from emulator", supi), data)
+			}
+			return nil
+		},
+	}
+}
+
+// newAddGnbCommand builds the command adding a gNB to the emulator
+func (s *CommandStore) newAddGnbCommand() *cli.Command {
+	return &cli.Command{
+		Name:        "add-gnb",
+		Usage:       "Add a new gNB",
+		ArgsUsage:   "<name>",
+		Description: "Add a new gNB to the emulator with the specified name",
+		Metadata: withSchema(CommandSchema{
+			Args: []models.ArgInfo{gnbNameArg},
+		}),
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "ng-setup",
+				Usage: "Trigger NG Setup after adding",
+			},
+		},
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			name := cmd.Args().First()
+			ngSetup := cmd.Bool("ng-setup")
+			data := map[string]string{
+				"name":     name,
+				"ng-setup": strconv.FormatBool(ngSetup),
+			}
+			provisioner, ok := s.provisioner()
+			if !ok {
+				unsupported(ctx, "The emulator does not support adding gNBs")
+				return nil
+			}
+
+			if provisioner.AddGnb(name, ngSetup) {
+				s.notifyNodesChanged()
+				Succeed(ctx, fmt.Sprintf("gNB %s added successfully to emulator", name), data)
+			} else {
+				Fail(ctx, models.ErrCodeOperationFailed, fmt.Sprintf("Failed to add gNB %s to emulator", name), data)
+			}
+			return nil
+		},
+	}
+}
+
+// newRemoveGnbCommand builds the command removing a gNB from the emulator
+func (s *CommandStore) newRemoveGnbCommand() *cli.Command {
+	return &cli.Command{
+		Name:        "remove-gnb",
+		Usage:       "Remove a gNB",
+		ArgsUsage:   "<name>",
+		Description: "Remove the gNB with the specified name from the emulator",
+		Metadata: withSchema(CommandSchema{
+			Args: []models.ArgInfo{gnbNameArg},
+		}),
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			name := cmd.Args().First()
+			data := map[string]string{
+				"name": name,
+			}
+			provisioner, ok := s.provisioner()
+			if !ok {
+				unsupported(ctx, "The emulator does not support removing gNBs")
+				return nil
+			}
+
+			if provisioner.RemoveGnb(name) {
+				s.notifyNodesChanged()
+				Succeed(ctx, fmt.Sprintf("gNB %s removed successfully from emulator", name), data)
+			} else {
+				Fail(ctx, models.ErrCodeOperationFailed, fmt.Sprintf("Failed to remove gNB %s from emulator", name), data)
+			}
+			return nil
+		},
+	}
+}
+
+// newAddUeRangeCommand builds the command adding UEs with consecutive SUPIs
+func (s *CommandStore) newAddUeRangeCommand() *cli.Command {
+	return &cli.Command{
+		Name:        "add-ue-range",
+		Usage:       "Add UEs with consecutive SUPIs",
+		ArgsUsage:   "<start-supi> <count>",
+		Description: "Add count UEs to the emulator, from the start SUPI upwards, e.g. add-ue-range imsi-001010000000001 100",
+		Metadata: withSchema(CommandSchema{
+			Args: []models.ArgInfo{
+				{
+					Name:       "start-supi",
+					Usage:      "SUPI of the first UE, imsi-<15 digits>",
+					Required:   true,
+					ValueRules: supiArg.ValueRules,
+				},
+				{
+					Name:       "count",
+					Type:       models.FlagTypeInt,
+					Usage:      "Number of UEs to add",
+					Required:   true,
+					ValueRules: intRange(1, MaxUeRange),
+				},
+			},
+		}),
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "register",
+				Usage: "Trigger registration after adding",
+			},
+		},
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			start := cmd.Args().Get(0)
+			register := cmd.Bool("register")
+
+			count, err := strconv.Atoi(cmd.Args().Get(1))
+			if err != nil {
+				Fail(ctx, models.ErrCodeInvalidRequest, fmt.Sprintf("invalid count %q", cmd.Args().Get(1)), nil)
+				return nil
+			}
+			supis, err := supiRange(start, count)
+			if err != nil {
+				Fail(ctx, models.ErrCodeInvalidRequest, err.Error(), nil)
+				return nil
+			}
+
+			added := 0
+			var failed []string
+			for _, supi := range supis {
+				if ctx.Err() != nil {
+					break
+				}
+				if s.eApi.AddUe(supi, register) {
+					added++
+				} else {
+					failed = append(failed, supi)
+				}
+			}
+			if added > 0 {
+				s.notifyNodesChanged()
+			}
+
+			data := map[string]string{
+				"first":    supis[0],
+				"last":     supis[len(supis)-1],
+				"register": strconv.FormatBool(register),
+				"added":    strconv.Itoa(added),
+				"failed":   strconv.Itoa(len(failed)),
+			}
+			if len(failed) > 0 {
+				data["failed-supis"] = strings.Join(failed, ", ")
+			}
+			if added+len(failed) < len(supis) {
+				code, reason := models.ErrCodeCanceled, "was canceled"
+				if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+					code, reason = models.ErrCodeTimeout, "timed out"
+				}
+				Fail(ctx, code, fmt.Sprintf("Added %d of %d UEs to emulator before the command %s", added, len(supis), reason), data)
+				return nil
+			}
+			if len(failed) > 0 {
+				Fail(ctx, models.ErrCodeOperationFailed, fmt.Sprintf("Added %d of %d UEs to emulator", added, len(supis)), data)
+				return nil
+			}
+			Succeed(ctx, fmt.Sprintf("%d UEs added successfully to emulator", added), data)
+			return nil
+		},
+	}
+}
+
+// supiRange returns count consecutive IMSI based SUPIs from start
+func supiRange(start string, count int) ([]string, error) {
+	digits := strings.TrimPrefix(start, "imsi-")
+	first, err := strconv.ParseUint(digits, 10, 64)
+	if err != nil || len(digits) != 15 {
+		return nil, fmt.Errorf("invalid start SUPI %q", start)
+	}
+
+	if count < 1 {
+		return nil, fmt.Errorf("invalid count %d, expected a positive number of UEs", count)
+	}
+	last := first + uint64(count) - 1
+	if last > 999_999_999_999_999 {
+		return nil, fmt.Errorf("the range of %d UEs from %s exceeds 15 digits", count, start)
+	}
+
+	supis := make([]string, 0, count)
+	for imsi := first; imsi <= last; imsi++ {
+		supis = append(supis, fmt.Sprintf("imsi-%015d", imsi))
+	}
+	return supis, nil
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/TutuanHo03/remote-control/models"
+)
+
+func TestAddUeRangeCanceled(t *testing.T) {
+	emu := newFakeEmulator()
+	store := NewCommandStore(emu, emu, emu)
+
+	rspCh := make(chan models.CommandResult, 1)
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), "rsp", rspCh))
+	defer cancel()
+
+	// Cancel once the third UE of the range is added
+	calls := 0
+	emu.onAddUe = func(string) {
+		if calls++; calls == 3 {
+			cancel()
+		}
+	}
+
+	cmd := store.newAddUeRangeCommand()
+	if err := cmd.Run(ctx, []string{cmd.Name, "imsi-001010000000100", "10"}); err != nil {
+		t.Fatal(err)
+	}
+
+	result := <-rspCh
+	if result.Status != models.StatusFailure || result.ErrorCode != models.ErrCodeCanceled {
+		t.Errorf("got %s %s, want %s %s", result.Status, result.ErrorCode, models.StatusFailure, models.ErrCodeCanceled)
+	}
+	if result.Data["added"] != "3" || result.Data["failed"] != "0" {
+		t.Errorf("got %s added and %s failed, want 3 added and 0 failed", result.Data["added"], result.Data["failed"])
+	}
+	if want := "Added 3 of 10 UEs to emulator before the command was canceled"; result.Message != want {
+		t.Errorf("got message %q, want %q", result.Message, want)
+	}
+}
+
+func TestExecuteAddUeRangeCanceled(t *testing.T) {
+	emu := newFakeEmulator()
+	store := NewCommandStore(emu, emu, emu)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	calls := 0
+	emu.onAddUe = func(string) {
+		if calls++; calls == 3 {
+			cancel()
+		}
+	}
+
+	// The partial result reaches the caller instead of a bare cancel error
+	req := models.CommandRequest{NodeType: "emulator", RawCommand: "add-ue-range imsi-001010000000100 10"}
+	response, err := store.ExecuteCommand(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result := response.Result; result.ErrorCode != models.ErrCodeCanceled || result.Data["added"] != "3" {
+		t.Errorf("got %s with %s added, want %s with 3 added", result.ErrorCode, result.Data["added"], models.ErrCodeCanceled)
+	}
+}
@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/TutuanHo03/remote-control/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// eventBufferSize is the number of events buffered per subscriber;
+	// events for subscribers that fall further behind are dropped
+	eventBufferSize = 64
+	// eventHeartbeat is the interval of keep-alive events on idle streams
+	eventHeartbeat = 15 * time.Second
+)
+
+// EventSource is implemented by emulators that publish node state changes
+// such as registrations, PDU sessions or released UEs; node added and removed
+// events are published by the node sync, not by the emulator
+type EventSource interface {
+	Subscribe(handler func(models.NodeEvent)) (unsubscribe func())
+}
+
+// EventBroker - Fans node events out to the connected event streams
+type EventBroker struct {
+	mu          sync.Mutex
+	subscribers map[chan models.NodeEvent]struct{}
+	closed      bool
+	unsubscribe func()
+}
+
+// NewEventBroker creates a broker relaying the events of the emulator when
+// it implements EventSource
+func NewEventBroker(eApi EmulatorApi) *EventBroker {
+	b := &EventBroker{
+		subscribers: make(map[chan models.NodeEvent]struct{}),
+	}
+
+	if source, ok := eApi.(EventSource); ok {
+		b.unsubscribe = source.Subscribe(b.Publish)
+	}
+
+	return b
+}
+
+// Publish sends an event to every subscriber
+func (b *EventBroker) Publish(event models.NodeEvent) {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch := range b.subscribers {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// Subscribe registers a new event stream
+func (b *EventBroker) Subscribe() (<-chan models.NodeEvent, func()) {
+	ch := make(chan models.NodeEvent, eventBufferSize)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		close(ch)
+		return ch, func() {}
+	}
+	b.subscribers[ch] = struct{}{}
+
+	return ch, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if _, exists := b.subscribers[ch]; exists {
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// Close detaches from the emulator and ends all event streams
+func (b *EventBroker) Close() {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return
+	}
+	b.closed = true
+	for ch := range b.subscribers {
+		delete(b.subscribers, ch)
+		close(ch)
+	}
+	b.mu.Unlock()
+
+	// Outside the lock, the emulator may be publishing while unsubscribing
+	if b.unsubscribe != nil {
+		b.unsubscribe()
+	}
+}
+
+// StreamEvents streams node events as server-sent events, optionally
+// filtered by the nodeType and nodeName query parameters
+func (b *EventBroker) StreamEvents(c *gin.Context) {
+	nodeType := c.Query("nodeType")
+	nodeName := c.Query("nodeName")
+
+	events, unsubscribe := b.Subscribe()
+	defer unsubscribe()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(eventHeartbeat)
+	defer heartbeat.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case <-heartbeat.C:
+			c.SSEvent("heartbeat", time.Now())
+			return true
+		case event, ok := <-events:
+			if !ok {
+				return false
+			}
+			if (nodeType == "" || event.NodeType == nodeType) && (nodeName == "" || event.NodeName == nodeName) {
+				c.SSEvent(string(event.Type), event)
+			}
+			return true
+		}
+	})
+}
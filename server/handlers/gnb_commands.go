@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/TutuanHo03/remote-control/models"
+
+	"github.com/urfave/cli/v3"
+)
+
+// GnbInspector is implemented by gNBs that report their UE contexts, NG state
+// and configuration, for the list-ues, ng-status and show-config commands
+type GnbInspector interface {
+	ConnectedUes() ([]models.GnbUe, error)
+	NgStatus() (models.NgStatus, error)
+	Config() (models.GnbConfig, error)
+}
+
+// NgController is implemented by gNBs that can run the NG Setup and NG Reset
+// procedures on demand; an empty AMF name targets every AMF
+type NgController interface {
+	NgSetup(amf string) error
+	NgReset(amf string) error
+}
+
+// gnbInspector returns the gNB of the current execution if it reports its state
+func gnbInspector(ctx context.Context) (GnbInspector, bool) {
+	inspector, ok := GetNode(ctx).(GnbInspector)
+	return inspector, ok
+}
+
+// ngController returns the gNB of the current execution if it runs NG procedures
+func ngController(ctx context.Context) (NgController, bool) {
+	controller, ok := GetNode(ctx).(NgController)
+	return controller, ok
+}
+
+// newListGnbUesCommand builds the command listing the UE contexts of a gNB
+func (s *CommandStore) newListGnbUesCommand() *cli.Command {
+	return &cli.Command{
+		Name:        "list-ues",
+		Usage:       "List the connected UEs",
+		Description: "List the UEs connected to the gNB with their RAN and AMF UE NGAP IDs",
+		Metadata: withSchema(CommandSchema{
+			Args: []models.ArgInfo{},
+		}),
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			nodeName, _ := GetNodeName(ctx)
+			inspector, ok := gnbInspector(ctx)
+			if !ok {
+				unsupported(ctx, fmt.Sprintf("gNB %s does not report its UEs", nodeName))
+				return nil
+			}
+
+			ues, err := inspector.ConnectedUes()
+			if err != nil {
+				Fail(ctx, models.ErrCodeOperationFailed, fmt.Sprintf("Failed to list the UEs of gNB %s: %v", nodeName, err), nil)
+				return nil
+			}
+
+			table := &models.Table{Columns: []string{"UE-ID", "SUPI", "RAN-UE-NGAP-ID", "AMF-UE-NGAP-ID", "STATE"}}
+			for _, ue := range ues {
+				table.Rows = append(table.Rows, []string{
+					ue.UeID, ue.Supi, strconv.FormatInt(ue.RanUeNgapID, 10), strconv.FormatInt(ue.AmfUeNgapID, 10), ue.State,
+				})
+			}
+
+			SucceedWithTable(ctx, fmt.Sprintf("gNB %s has %d connected UE(s)", nodeName, len(ues)), nil, table)
+			return nil
+		},
+	}
+}
+
+// newNgStatusCommand builds the command showing the NG state and AMFs of a gNB
+func (s *CommandStore) newNgStatusCommand() *cli.Command {
+	return &cli.Command{
+		Name:        "ng-status",
+		Usage:       "Show the NG Setup status",
+		Description: "Show the NG Setup status of the gNB and its AMF associations",
+		Metadata: withSchema(CommandSchema{
+			Args: []models.ArgInfo{},
+		}),
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			nodeName, _ := GetNodeName(ctx)
+			inspector, ok := gnbInspector(ctx)
+			if !ok {
+				unsupported(ctx, fmt.Sprintf("gNB %s does not report its NG status", nodeName))
+				return nil
+			}
+
+			status, err := inspector.NgStatus()
+			if err != nil {
+				Fail(ctx, models.ErrCodeOperationFailed, fmt.Sprintf("Failed to get the NG status of gNB %s: %v", nodeName, err), nil)
+				return nil
+			}
+
+			table := &models.Table{Columns: []string{"AMF", "ADDRESS", "STATE", "CAPACITY"}}
+			for _, amf := range status.Amfs {
+				table.Rows = append(table.Rows, []string{amf.Name, amf.Address, amf.State, strconv.Itoa(amf.Capacity)})
+			}
+
+			SucceedWithTable(ctx, fmt.Sprintf("NG status of gNB %s", nodeName), map[string]string{
+				"ng-setup": status.State,
+				"amfs":     strconv.Itoa(len(status.Amfs)),
+			}, table)
+			return nil
+		},
+	}
+}
+
+// newGnbShowConfigCommand builds the command showing the configuration of a gNB
+func (s *CommandStore) newGnbShowConfigCommand() *cli.Command {
+	return &cli.Command{
+		Name:        "show-config",
+		Usage:       "Show the gNB configuration",
+		Description: "Show the gNB ID, supported PLMNs, tracking areas and slices, and the NGAP and GTP addresses of the gNB",
+		Metadata: withSchema(CommandSchema{
+			Args: []models.ArgInfo{},
+		}),
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			nodeName, _ := GetNodeName(ctx)
+			inspector, ok := gnbInspector(ctx)
+			if !ok {
+				unsupported(ctx, fmt.Sprintf("gNB %s does not report its configuration", nodeName))
+				return nil
+			}
+
+			config, err := inspector.Config()
+			if err != nil {
+				Fail(ctx, models.ErrCodeOperationFailed, fmt.Sprintf("Failed to get the configuration of gNB %s: %v", nodeName, err), nil)
+				return nil
+			}
+
+			Succeed(ctx, fmt.Sprintf("Configuration of gNB %s", nodeName), map[string]string{
+				"gnb-id":       config.GnbID,
+				"plmns":        strings.Join(config.Plmns, ", "),
+				"tacs":         strings.Join(config.Tacs, ", "),
+				"slices":       strings.Join(config.Slices, ", "),
+				"ngap-address": config.NgapAddress,
+				"gtp-address":  config.GtpAddress,
+			})
+			return nil
+		},
+	}
+}
+
+// newNgProcedureCommand builds the command triggering NG Setup or NG Reset
+func (s *CommandStore) newNgProcedureCommand(name, procedure string, run func(NgController, string) error) *cli.Command {
+	return &cli.Command{
+		Name:        name,
+		Usage:       "Trigger " + procedure,
+		Description: "Trigger " + procedure + " towards one AMF, or towards every AMF when --amf is not given",
+		Metadata: withSchema(CommandSchema{
+			Args: []models.ArgInfo{},
+		}),
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "amf",
+				Usage: "Name of the AMF, all AMFs when empty",
+			},
+		},
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			nodeName, _ := GetNodeName(ctx)
+			controller, ok := ngController(ctx)
+			if !ok {
+				unsupported(ctx, fmt.Sprintf("gNB %s does not support %s on demand", nodeName, procedure))
+				return nil
+			}
+
+			amf := cmd.String("amf")
+			target := "all AMFs"
+			if amf != "" {
+				target = "AMF " + amf
+			}
+			data := map[string]string{
+				"amf": amf,
+			}
+
+			if err := run(controller, amf); err != nil {
+				Fail(ctx, models.ErrCodeOperationFailed, fmt.Sprintf("%s of gNB %s towards %s failed: %v", procedure, nodeName, target, err), data)
+				return nil
+			}
+			Succeed(ctx, fmt.Sprintf("%s of gNB %s towards %s completed", procedure, nodeName, target), data)
+			return nil
+		},
+	}
+}
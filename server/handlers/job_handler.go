@@ -0,0 +1,359 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/TutuanHo03/remote-control/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// DefaultJobWorkers is the number of jobs executed concurrently
+	DefaultJobWorkers = 8
+	// jobQueueSize is the number of pending jobs accepted before rejecting new ones
+	jobQueueSize = 1024
+	// jobRetention is how long finished jobs stay queryable
+	jobRetention = time.Hour
+)
+
+var (
+	// ErrJobNotFound is returned for unknown job IDs
+	ErrJobNotFound = errors.New("job not found")
+	// ErrJobQueueFull is returned when too many jobs are pending
+	ErrJobQueueFull = errors.New("job queue is full")
+	// ErrJobsClosed is returned for jobs submitted after Close
+	ErrJobsClosed = errors.New("job manager is shutting down")
+)
+
+// job - A queued command request and its cancellation
+type job struct {
+	info   models.JobInfo // Guarded by JobManager.mu
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// JobManager - Executes command requests asynchronously on a worker pool
+type JobManager struct {
+	commandStore *CommandStore
+	sessions     *SessionManager
+
+	mu      sync.RWMutex
+	jobs    map[string]*job
+	queue   chan *job
+	closed  bool
+	workers sync.WaitGroup
+}
+
+// NewJobManager creates a job manager and starts its workers
+func NewJobManager(commandStore *CommandStore, sessions *SessionManager, workers int) *JobManager {
+	if workers <= 0 {
+		workers = DefaultJobWorkers
+	}
+
+	m := &JobManager{
+		commandStore: commandStore,
+		sessions:     sessions,
+		jobs:         make(map[string]*job),
+		queue:        make(chan *job, jobQueueSize),
+	}
+
+	m.workers.Add(workers)
+	for i := 0; i < workers; i++ {
+		go m.worker()
+	}
+
+	return m
+}
+
+// Submit queues a command request and returns the pending job
+func (m *JobManager) Submit(ctx context.Context, req models.CommandRequest) (models.JobInfo, error) {
+	// The job outlives the request but keeps its values, such as the caller
+	ctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	j := &job{
+		info: models.JobInfo{
+			ID:        newID(),
+			State:     models.JobPending,
+			Request:   req,
+			CreatedAt: time.Now(),
+		},
+		ctx:    ctx,
+		cancel: cancel,
+	}
+	if principal, ok := GetPrincipal(ctx); ok {
+		j.info.User = principal.Subject
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.closed {
+		cancel()
+		return models.JobInfo{}, ErrJobsClosed
+	}
+	m.pruneLocked(j.info.CreatedAt)
+
+	select {
+	case m.queue <- j:
+	default:
+		cancel()
+		return models.JobInfo{}, ErrJobQueueFull
+	}
+	m.jobs[j.info.ID] = j
+
+	return j.info, nil
+}
+
+// Get returns the current state of a job
+func (m *JobManager) Get(id string) (models.JobInfo, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	j, exists := m.jobs[id]
+	if !exists {
+		return models.JobInfo{}, ErrJobNotFound
+	}
+	return j.info, nil
+}
+
+// List returns all known jobs, newest first
+func (m *JobManager) List() []models.JobInfo {
+	m.mu.RLock()
+	infos := make([]models.JobInfo, 0, len(m.jobs))
+	for _, j := range m.jobs {
+		infos = append(infos, j.info)
+	}
+	m.mu.RUnlock()
+
+	sort.Slice(infos, func(i, k int) bool {
+		return infos[i].CreatedAt.After(infos[k].CreatedAt)
+	})
+	return infos
+}
+
+// Cancel stops a pending or running job
+func (m *JobManager) Cancel(id string) (models.JobInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	j, exists := m.jobs[id]
+	if !exists {
+		return models.JobInfo{}, ErrJobNotFound
+	}
+
+	// Pending jobs are finished right away, running ones once the command returns
+	if j.info.State == models.JobPending {
+		m.finishLocked(j, models.JobCanceled, nil)
+	}
+	j.cancel()
+
+	return j.info, nil
+}
+
+// Close cancels all jobs and stops the workers
+func (m *JobManager) Close() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.closeLocked()
+	for _, j := range m.jobs {
+		j.cancel()
+	}
+}
+
+// Shutdown stops accepting jobs and waits for the pending and running ones
+// to finish; those left when ctx is done are canceled
+func (m *JobManager) Shutdown(ctx context.Context) error {
+	m.mu.Lock()
+	m.closeLocked()
+	m.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		m.workers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		m.mu.RLock()
+		unfinished := 0
+		for _, j := range m.jobs {
+			if !j.info.State.Finished() {
+				unfinished++
+			}
+		}
+		m.mu.RUnlock()
+
+		// Canceled commands return right away, so the workers stop quickly
+		m.Close()
+		<-done
+		if unfinished == 0 {
+			return nil
+		}
+		return fmt.Errorf("%w, %d job(s) canceled", ctx.Err(), unfinished)
+	}
+}
+
+// closeLocked rejects new jobs and lets the workers stop once the queue is empty
+func (m *JobManager) closeLocked() {
+	if m.closed {
+		return
+	}
+	m.closed = true
+	close(m.queue)
+}
+
+// worker executes queued jobs until the queue is closed
+func (m *JobManager) worker() {
+	defer m.workers.Done()
+	for j := range m.queue {
+		m.run(j)
+	}
+}
+
+// run executes one job and records its outcome
+func (m *JobManager) run(j *job) {
+	m.mu.Lock()
+	if j.info.State != models.JobPending {
+		m.mu.Unlock()
+		return
+	}
+	if j.ctx.Err() != nil {
+		m.finishLocked(j, models.JobCanceled, nil)
+		m.mu.Unlock()
+		return
+	}
+	now := time.Now()
+	j.info.State = models.JobRunning
+	j.info.StartedAt = &now
+	req := j.info.Request
+	m.mu.Unlock()
+
+	response, err := m.commandStore.ExecuteCommand(withAuditSource(j.ctx, models.AuditJob), req)
+	if err != nil {
+		_, response = commandErrorResponse(req, err)
+	}
+
+	state := models.JobSucceeded
+	switch {
+	case errors.Is(err, ErrCommandCanceled), err == nil && response.Result != nil && response.Result.ErrorCode == models.ErrCodeCanceled:
+		state = models.JobCanceled
+	case err != nil || !response.Result.Succeeded():
+		state = models.JobFailed
+	}
+
+	m.mu.Lock()
+	m.finishLocked(j, state, &response)
+	m.mu.Unlock()
+}
+
+// finishLocked moves a job to its final state
+func (m *JobManager) finishLocked(j *job, state models.JobState, response *models.CommandResponse) {
+	now := time.Now()
+	j.info.State = state
+	j.info.Response = response
+	j.info.FinishedAt = &now
+	j.cancel()
+}
+
+// pruneLocked forgets jobs finished longer than the retention period ago
+func (m *JobManager) pruneLocked(now time.Time) {
+	for id, j := range m.jobs {
+		if j.info.FinishedAt != nil && now.Sub(*j.info.FinishedAt) > jobRetention {
+			delete(m.jobs, id)
+		}
+	}
+}
+
+// SubmitJob handles POST /api/jobs
+func (m *JobManager) SubmitJob(c *gin.Context) {
+	var req models.CommandRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format: " + err.Error()})
+		return
+	}
+
+	if _, err := m.sessions.ApplyToRequest(c.Request.Context(), &req); err != nil {
+		status := http.StatusGone
+		if errors.Is(err, ErrSessionNotOwned) {
+			status = http.StatusForbidden
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := m.commandStore.Authorize(c.Request.Context(), req); err != nil {
+		status, response := commandErrorResponse(req, err)
+		c.JSON(status, gin.H{"error": response.Error})
+		return
+	}
+
+	info, err := m.Submit(withClientIP(c.Request.Context(), c.ClientIP()), req)
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, info)
+}
+
+// canAccess reports whether the caller of a context may see and cancel a job:
+// admins reach every job, other callers the jobs they submitted
+func canAccess(ctx context.Context, info models.JobInfo) bool {
+	principal, ok := GetPrincipal(ctx)
+	if !ok {
+		// Authentication is off
+		return true
+	}
+	return principal.Role.Allows(RoleAdmin) || principal.Subject == info.User
+}
+
+// ListJobs handles GET /api/jobs
+func (m *JobManager) ListJobs(c *gin.Context) {
+	jobs := m.List()
+	visible := make([]models.JobInfo, 0, len(jobs))
+	for _, info := range jobs {
+		if canAccess(c.Request.Context(), info) {
+			visible = append(visible, info)
+		}
+	}
+	c.JSON(http.StatusOK, visible)
+}
+
+// GetJob handles GET /api/jobs/:id, jobs of other callers are not found
+func (m *JobManager) GetJob(c *gin.Context) {
+	info, err := m.Get(c.Param("id"))
+	if err == nil && !canAccess(c.Request.Context(), info) {
+		err = ErrJobNotFound
+	}
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, info)
+}
+
+// CancelJob handles DELETE /api/jobs/:id, jobs of other callers are not found
+func (m *JobManager) CancelJob(c *gin.Context) {
+	info, err := m.Get(c.Param("id"))
+	if err == nil && !canAccess(c.Request.Context(), info) {
+		err = ErrJobNotFound
+	}
+	if err == nil {
+		info, err = m.Cancel(info.ID)
+	}
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, info)
+}
@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/TutuanHo03/remote-control/models"
+
+	"github.com/urfave/cli/v3"
+)
+
+// NodeKey is the context key of the node resolved for the current execution
+const NodeKey = "node"
+
+// NodeTypeSpec - Definition of a kind of node controlled through the server.
+// Library users register their own types, e.g. an AMF or a UPF, with
+// CommandStore.RegisterNodeType or Server.RegisterNodeType.
+type NodeTypeSpec struct {
+	Name        string // Name of the context set, as given to use
+	Description string // Description of the context set
+
+	// ListNodes returns the names of the nodes currently available
+	ListNodes func() []string
+
+	// Resolve returns the node given to the command actions through GetNode,
+	// or an error when it does not exist; types without it pass no node
+	Resolve func(name string) (any, error)
+
+	// Commands builds the command tree of the type. It is called for every
+	// execution because cli.Command keeps parse state while running; actions
+	// answer with Succeed or Fail.
+	Commands func() *cli.Command
+
+	// Singleton types have a single node named after the type, use enters it
+	// directly and ListNodes is not needed
+	Singleton bool
+}
+
+// registeredType is a node type with its cached command definitions
+type registeredType struct {
+	spec     NodeTypeSpec
+	tree     *cli.Command              // Definitions used for metadata and help
+	commands []models.CommandInfo      // Metadata of the commands of the tree
+	patterns map[string]*regexp.Regexp // Compiled value patterns of the schemas
+}
+
+// RegisterNodeType adds a node type to the store; the context handler creates
+// its context set on the next node sync. The value patterns of the command
+// schemas are compiled here, an invalid one fails the registration.
+func (s *CommandStore) RegisterNodeType(spec NodeTypeSpec) error {
+	switch {
+	case spec.Name == "" || strings.ContainsAny(spec.Name, ": /"):
+		return fmt.Errorf("%w: invalid name %q", ErrInvalidNodeType, spec.Name)
+	case spec.Commands == nil:
+		return fmt.Errorf("%w: %s has no commands", ErrInvalidNodeType, spec.Name)
+	case spec.ListNodes == nil && !spec.Singleton:
+		return fmt.Errorf("%w: %s has no node lister", ErrInvalidNodeType, spec.Name)
+	}
+	if spec.Description == "" {
+		spec.Description = strings.ToUpper(spec.Name) + " context set"
+	}
+
+	tree := spec.Commands()
+	patterns := make(map[string]*regexp.Regexp)
+	if err := compilePatterns(tree, patterns); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidNodeType, err)
+	}
+
+	s.typesMu.Lock()
+	defer s.typesMu.Unlock()
+
+	if _, exists := s.types[spec.Name]; exists {
+		return fmt.Errorf("%w: %s is already registered", ErrInvalidNodeType, spec.Name)
+	}
+	s.types[spec.Name] = &registeredType{
+		spec:     spec,
+		tree:     tree,
+		commands: s.convertCommandInfos(tree.Commands),
+		patterns: patterns,
+	}
+	s.typeOrder = append(s.typeOrder, spec.Name)
+	return nil
+}
+
+// NodeTypes returns the registered node types in registration order
+func (s *CommandStore) NodeTypes() []NodeTypeSpec {
+	s.typesMu.RLock()
+	defer s.typesMu.RUnlock()
+
+	specs := make([]NodeTypeSpec, 0, len(s.typeOrder))
+	for _, name := range s.typeOrder {
+		specs = append(specs, s.types[name].spec)
+	}
+	return specs
+}
+
+// nodeType returns a registered node type by name
+func (s *CommandStore) nodeType(name string) (*registeredType, bool) {
+	s.typesMu.RLock()
+	defer s.typesMu.RUnlock()
+
+	rt, ok := s.types[name]
+	return rt, ok
+}
+
+// nodeTypeNames lists the registered node types for messages
+func (s *CommandStore) nodeTypeNames() []string {
+	s.typesMu.RLock()
+	defer s.typesMu.RUnlock()
+
+	return append([]string(nil), s.typeOrder...)
+}
+
+// registerBuiltinTypes registers the emulator, UE and gNB node types
+func (s *CommandStore) registerBuiltinTypes() {
+	builtins := []NodeTypeSpec{
+		{
+			Name:      "ue",
+			ListNodes: s.eApi.ListUes,
+			Resolve: func(name string) (any, error) {
+				return s.ueProvider.GetUe(name)
+			},
+			Commands: s.newUeCommand,
+		},
+		{
+			Name:      "gnb",
+			ListNodes: s.eApi.ListGnbs,
+			Resolve: func(name string) (any, error) {
+				return s.gnbProvider.GetGnb(name)
+			},
+			Commands: s.newGnbCommand,
+		},
+		{
+			Name:        "emulator",
+			Description: "Emulator control context",
+			Commands:    s.newEmulatorCommand,
+			Singleton:   true,
+		},
+	}
+
+	for _, spec := range builtins {
+		if err := s.RegisterNodeType(spec); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// GetNode returns the node resolved for the current execution, as returned by
+// the Resolve function of its type
+func GetNode(ctx context.Context) any {
+	return ctx.Value(NodeKey)
+}
+
+// Succeed sends a successful result back to ExecuteCommand
+func Succeed(ctx context.Context, message string, data map[string]string) {
+	ctx.Value("rsp").(chan models.CommandResult) <- models.CommandResult{
+		Status:  models.StatusSuccess,
+		Message: message,
+		Data:    data,
+	}
+}
+
+// SucceedWithTable sends a successful result with a tabular payload back to
+// ExecuteCommand
+func SucceedWithTable(ctx context.Context, message string, data map[string]string, table *models.Table) {
+	ctx.Value("rsp").(chan models.CommandResult) <- models.CommandResult{
+		Status:  models.StatusSuccess,
+		Message: message,
+		Data:    data,
+		Table:   table,
+	}
+}
+
+// Fail sends a failed result with its error code back to ExecuteCommand
+func Fail(ctx context.Context, code string, message string, data map[string]string) {
+	ctx.Value("rsp").(chan models.CommandResult) <- models.CommandResult{
+		Status:    models.StatusFailure,
+		ErrorCode: code,
+		Message:   message,
+		Data:      data,
+	}
+}
+
+// errUnregisteredType builds the error of a request for an unknown node type
+func (s *CommandStore) errUnregisteredType(nodeType string) error {
+	return fmt.Errorf("%w %q, valid types: %s", ErrInvalidNodeType, nodeType, strings.Join(s.nodeTypeNames(), ", "))
+}
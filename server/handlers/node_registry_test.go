@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/TutuanHo03/remote-control/models"
+
+	"github.com/urfave/cli/v3"
+)
+
+// newPatternType returns a singleton node type with a command checking its
+// argument against pattern
+func newPatternType(name, pattern string) NodeTypeSpec {
+	return NodeTypeSpec{
+		Name:      name,
+		Singleton: true,
+		Commands: func() *cli.Command {
+			return &cli.Command{
+				Name: name,
+				Commands: []*cli.Command{
+					{
+						Name: "echo",
+						Metadata: withSchema(CommandSchema{
+							Args: []models.ArgInfo{{Name: "word", Required: true, ValueRules: models.ValueRules{Pattern: pattern}}},
+						}),
+						Action: func(ctx context.Context, cmd *cli.Command) error {
+							Succeed(ctx, cmd.Args().First(), nil)
+							return nil
+						},
+					},
+				},
+			}
+		},
+	}
+}
+
+func TestRegisterNodeTypePatterns(t *testing.T) {
+	emu := newFakeEmulator()
+	store := NewCommandStore(emu, emu, emu)
+
+	err := store.RegisterNodeType(newPatternType("broken", `^[a-z+$`))
+	if !errors.Is(err, ErrInvalidNodeType) || !strings.Contains(err.Error(), "broken echo: invalid pattern") {
+		t.Errorf("got error %v, want an invalid pattern of broken echo", err)
+	}
+	if _, ok := store.nodeType("broken"); ok {
+		t.Error("type with an invalid pattern registered")
+	}
+
+	if err := store.RegisterNodeType(newPatternType("words", `^[a-z]+$`)); err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		word  string
+		valid bool
+	}{
+		{"hello", true},
+		{"Hello", false},
+		{"42", false},
+	} {
+		req := models.CommandRequest{NodeType: "words", NodeName: "words", CommandPath: "echo", Args: []string{tc.word}}
+		response, err := store.ExecuteCommand(context.Background(), req)
+		var verr *ValidationError
+		switch {
+		case tc.valid && err != nil:
+			t.Errorf("%s: %v", tc.word, err)
+		case tc.valid && response.Result.Message != tc.word:
+			t.Errorf("%s: got %+v", tc.word, response.Result)
+		case !tc.valid && !errors.As(err, &verr):
+			t.Errorf("%s: got error %v, want a validation error", tc.word, err)
+		}
+	}
+}
@@ -0,0 +1,348 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/TutuanHo03/remote-control/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultSessionTimeout is the idle time after which a session expires
+const DefaultSessionTimeout = 30 * time.Minute
+
+var (
+	// ErrSessionExpired is returned for requests of unknown or expired sessions
+	ErrSessionExpired = errors.New("session not found or expired, please connect again")
+	// ErrSessionNotOwned is returned for requests in the session of another caller
+	ErrSessionNotOwned = fmt.Errorf("%w: the session belongs to another caller", ErrForbidden)
+)
+
+// Session - Navigation state of one connected operator
+type Session struct {
+	ID         string
+	RemoteAddr string
+	ServerURL  string
+	User       string // Subject of the caller that connected, empty when authentication is off
+	CreatedAt  time.Time
+
+	mu         sync.Mutex
+	lastActive time.Time
+	stack      []*Context // Context stack, the last element is the current context
+	notices    []string   // Messages for the operator, sent with the next response
+}
+
+// Current returns the context the session is currently in
+func (s *Session) Current() *Context {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stack[len(s.stack)-1]
+}
+
+// Push enters a new context
+func (s *Session) Push(ctx *Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stack = append(s.stack, ctx)
+}
+
+// Pop leaves the current context and returns the one below it
+func (s *Session) Pop() (*Context, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.stack) <= 1 {
+		return nil, false
+	}
+	s.stack = s.stack[:len(s.stack)-1]
+	return s.stack[len(s.stack)-1], true
+}
+
+// Unwind drops contexts above the first context of the given type
+func (s *Session) Unwind(contextType ContextType) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, ctx := range s.stack {
+		if ctx.Type == contextType {
+			s.stack = s.stack[:i+1]
+			return
+		}
+	}
+}
+
+// TakeNotices returns and clears the pending notices of the session
+func (s *Session) TakeNotices() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	notices := s.notices
+	s.notices = nil
+	return notices
+}
+
+// leaveContext drops ctx and everything above it from the stack
+func (s *Session) leaveContext(ctx *Context) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, stacked := range s.stack {
+		if stacked == ctx && i > 0 {
+			s.stack = s.stack[:i]
+			s.notices = append(s.notices, fmt.Sprintf("Node %s (%s) is gone, back to %s context",
+				ctx.Name, ctx.NodeType, s.stack[i-1].Name))
+			return true
+		}
+	}
+	return false
+}
+
+// ownedBy reports whether the caller of a context may use the session:
+// admins use every session, other callers the sessions they connected
+func (s *Session) ownedBy(ctx context.Context) bool {
+	principal, ok := GetPrincipal(ctx)
+	if !ok {
+		// Authentication is off
+		return true
+	}
+	return principal.Role.Allows(RoleAdmin) || principal.Subject == s.User
+}
+
+// touch marks the session as active
+func (s *Session) touch(now time.Time) {
+	s.mu.Lock()
+	s.lastActive = now
+	s.mu.Unlock()
+}
+
+// idleSince returns the time of the last request of the session
+func (s *Session) idleSince() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastActive
+}
+
+// info converts the session to its API representation
+func (s *Session) info() models.SessionInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	path := make([]string, len(s.stack))
+	for i, ctx := range s.stack {
+		path[i] = ctx.Name
+	}
+	current := s.stack[len(s.stack)-1]
+
+	info := models.SessionInfo{
+		ID:          s.ID,
+		RemoteAddr:  s.RemoteAddr,
+		ServerURL:   s.ServerURL,
+		User:        s.User,
+		CreatedAt:   s.CreatedAt,
+		LastActive:  s.lastActive,
+		Path:        path,
+		ContextType: string(current.Type),
+		NodeType:    current.NodeType,
+	}
+	if current.Type == NodeType {
+		info.NodeName = current.Name
+	}
+	return info
+}
+
+// SessionManager - Tracks operator sessions and expires idle ones
+type SessionManager struct {
+	mu       sync.Mutex
+	sessions map[string]*Session
+	timeout  time.Duration
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewSessionManager creates a session manager and starts expiring sessions
+// idle for longer than timeout
+func NewSessionManager(timeout time.Duration) *SessionManager {
+	if timeout <= 0 {
+		timeout = DefaultSessionTimeout
+	}
+
+	m := &SessionManager{
+		sessions: make(map[string]*Session),
+		timeout:  timeout,
+		stop:     make(chan struct{}),
+	}
+
+	go m.expireLoop()
+
+	return m
+}
+
+// Create starts a new session of the caller of ctx positioned at the given
+// context stack
+func (m *SessionManager) Create(ctx context.Context, remoteAddr, serverURL string, stack ...*Context) *Session {
+	now := time.Now()
+	session := &Session{
+		ID:         newID(),
+		RemoteAddr: remoteAddr,
+		ServerURL:  serverURL,
+		CreatedAt:  now,
+		lastActive: now,
+		stack:      stack,
+	}
+	if principal, ok := GetPrincipal(ctx); ok {
+		session.User = principal.Subject
+	}
+
+	m.mu.Lock()
+	m.sessions[session.ID] = session
+	m.mu.Unlock()
+
+	return session
+}
+
+// Get returns an active session and marks it as used
+func (m *SessionManager) Get(id string) (*Session, bool) {
+	m.mu.Lock()
+	session, exists := m.sessions[id]
+	m.mu.Unlock()
+
+	if !exists {
+		return nil, false
+	}
+
+	now := time.Now()
+	if now.Sub(session.idleSince()) > m.timeout {
+		m.Remove(id)
+		return nil, false
+	}
+
+	session.touch(now)
+	return session, true
+}
+
+// Access returns an active session the caller of ctx may use, and marks it
+// as used; ErrSessionNotOwned when another caller connected it
+func (m *SessionManager) Access(ctx context.Context, id string) (*Session, error) {
+	m.mu.Lock()
+	session, exists := m.sessions[id]
+	m.mu.Unlock()
+
+	// Check the caller first, so other callers cannot keep the session alive
+	if exists && !session.ownedBy(ctx) {
+		return nil, ErrSessionNotOwned
+	}
+	if session, exists = m.Get(id); !exists {
+		return nil, ErrSessionExpired
+	}
+	return session, nil
+}
+
+// ApplyToRequest fills in the node of the request from the session it was
+// sent in and returns the pending notices of that session; the session must
+// belong to the caller of ctx
+func (m *SessionManager) ApplyToRequest(ctx context.Context, req *models.CommandRequest) ([]string, error) {
+	if req.SessionID == "" {
+		return nil, nil
+	}
+
+	session, err := m.Access(ctx, req.SessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if current := session.Current(); current.Type == NodeType && req.NodeType == "" {
+		req.NodeType = current.NodeType
+		req.NodeName = current.Name
+	}
+
+	return session.TakeNotices(), nil
+}
+
+// Remove ends a session
+func (m *SessionManager) Remove(id string) {
+	m.mu.Lock()
+	delete(m.sessions, id)
+	m.mu.Unlock()
+}
+
+// NodeRemoved moves every session sitting in a removed node context back
+// to the parent context and leaves a notice for the operator
+func (m *SessionManager) NodeRemoved(ctx *Context) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, session := range m.sessions {
+		session.leaveContext(ctx)
+	}
+}
+
+// List returns all active sessions ordered by creation time
+func (m *SessionManager) List() []models.SessionInfo {
+	m.mu.Lock()
+	sessions := make([]*Session, 0, len(m.sessions))
+	for _, session := range m.sessions {
+		sessions = append(sessions, session)
+	}
+	m.mu.Unlock()
+
+	infos := make([]models.SessionInfo, 0, len(sessions))
+	for _, session := range sessions {
+		infos = append(infos, session.info())
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].CreatedAt.Before(infos[j].CreatedAt)
+	})
+
+	return infos
+}
+
+// Close stops the expiry loop
+func (m *SessionManager) Close() {
+	m.stopOnce.Do(func() {
+		close(m.stop)
+	})
+}
+
+// expireLoop periodically removes idle sessions
+func (m *SessionManager) expireLoop() {
+	interval := m.timeout / 2
+	if interval > time.Minute {
+		interval = time.Minute
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.stop:
+			return
+		case now := <-ticker.C:
+			m.expire(now)
+		}
+	}
+}
+
+// expire removes sessions idle for longer than the timeout
+func (m *SessionManager) expire(now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for id, session := range m.sessions {
+		if now.Sub(session.idleSince()) > m.timeout {
+			delete(m.sessions, id)
+		}
+	}
+}
+
+// ListSessions returns the active operator sessions
+func (m *SessionManager) ListSessions(c *gin.Context) {
+	c.JSON(http.StatusOK, m.List())
+}
+
+// newID generates a random identifier for sessions and jobs
+func newID() string {
+	return rand.Text()
+}
@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/TutuanHo03/remote-control/models"
+
+	"github.com/urfave/cli/v3"
+)
+
+// UeInspector is implemented by UEs that report their state, PDU sessions and
+// configuration, for the status, list-sessions and show-config commands
+type UeInspector interface {
+	Status() (models.UeStatus, error)
+	Sessions() ([]models.PduSession, error)
+	Config() (models.UeConfig, error)
+}
+
+// SessionReleaser is implemented by UEs that release their own PDU sessions,
+// for the session release command
+type SessionReleaser interface {
+	ReleaseSession(sessionId uint8) error
+}
+
+// ueInspector returns the UE of the current execution if it reports its state
+func ueInspector(ctx context.Context) (UeInspector, bool) {
+	inspector, ok := GetNode(ctx).(UeInspector)
+	return inspector, ok
+}
+
+// newUeStatusCommand builds the command showing the RM/CM state of a UE
+func (s *CommandStore) newUeStatusCommand() *cli.Command {
+	return &cli.Command{
+		Name:        "status",
+		Usage:       "Show the UE state",
+		Description: "Show the RM/CM state, 5G-GUTI, allowed NSSAI and NAS security context of the UE",
+		Metadata: withSchema(CommandSchema{
+			Args: []models.ArgInfo{},
+		}),
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			nodeName, _ := GetNodeName(ctx)
+			inspector, ok := ueInspector(ctx)
+			if !ok {
+				unsupported(ctx, fmt.Sprintf("UE %s does not report its state", nodeName))
+				return nil
+			}
+
+			status, err := inspector.Status()
+			if err != nil {
+				Fail(ctx, models.ErrCodeOperationFailed, fmt.Sprintf("Failed to get the state of UE %s: %v", nodeName, err), nil)
+				return nil
+			}
+
+			table := fieldTable([][2]string{
+				{"rm-state", status.RmState},
+				{"cm-state", status.CmState},
+				{"guti", status.Guti},
+				{"allowed-nssai", strings.Join(status.AllowedNssai, ", ")},
+				{"security", securityText(status.Security)},
+			})
+			SucceedWithTable(ctx, fmt.Sprintf("State of UE %s", nodeName), nil, table)
+			return nil
+		},
+	}
+}
+
+// newListSessionsCommand builds the command listing the PDU sessions of a UE,
+// available as list-sessions and as session list
+func (s *CommandStore) newListSessionsCommand(name string) *cli.Command {
+	return &cli.Command{
+		Name:        name,
+		Usage:       "List the PDU sessions",
+		Description: "List the PDU sessions of the UE with their DNN, slice, type, state and address",
+		Metadata: withSchema(CommandSchema{
+			Args: []models.ArgInfo{},
+		}),
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			nodeName, _ := GetNodeName(ctx)
+			inspector, ok := ueInspector(ctx)
+			if !ok {
+				unsupported(ctx, fmt.Sprintf("UE %s does not report its PDU sessions", nodeName))
+				return nil
+			}
+
+			sessions, err := inspector.Sessions()
+			if err != nil {
+				Fail(ctx, models.ErrCodeOperationFailed, fmt.Sprintf("Failed to list the PDU sessions of UE %s: %v", nodeName, err), nil)
+				return nil
+			}
+
+			table := &models.Table{Columns: []string{"ID", "DNN", "SLICE", "TYPE", "STATE", "ADDRESS"}}
+			for _, session := range sessions {
+				table.Rows = append(table.Rows, []string{
+					strconv.Itoa(int(session.ID)), session.Dnn, session.Slice, session.Type, session.State, session.Address,
+				})
+			}
+
+			SucceedWithTable(ctx, fmt.Sprintf("UE %s has %d PDU session(s)", nodeName, len(sessions)), nil, table)
+			return nil
+		},
+	}
+}
+
+// newReleaseSessionCommand builds the command releasing a PDU session of a
+// UE, available as session release
+func (s *CommandStore) newReleaseSessionCommand() *cli.Command {
+	return &cli.Command{
+		Name:        "release",
+		Usage:       "Release a PDU session",
+		Description: "Release a PDU session of the UE, requested by the UE",
+		Metadata: withSchema(CommandSchema{
+			Args:  []models.ArgInfo{},
+			Flags: map[string]models.ValueRules{"id": intRange(1, 15)},
+		}),
+		Flags: []cli.Flag{
+			&cli.IntFlag{
+				Name:  "id",
+				Usage: "Session ID",
+				Value: 1,
+			},
+		},
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			nodeName, _ := GetNodeName(ctx)
+			releaser, ok := GetNode(ctx).(SessionReleaser)
+			if !ok {
+				unsupported(ctx, fmt.Sprintf("UE %s does not release its PDU sessions", nodeName))
+				return nil
+			}
+
+			sessionId := uint8(cmd.Int("id"))
+			data := map[string]string{
+				"sessionId": strconv.Itoa(int(sessionId)),
+			}
+			if err := releaser.ReleaseSession(sessionId); err != nil {
+				Fail(ctx, models.ErrCodeOperationFailed, fmt.Sprintf("Failed to release session %d of UE %s: %v", sessionId, nodeName, err), data)
+				return nil
+			}
+			Succeed(ctx, fmt.Sprintf("Session %d of UE %s released successfully", sessionId, nodeName), data)
+			return nil
+		},
+	}
+}
+
+// newShowConfigCommand builds the command showing the configuration of a UE
+func (s *CommandStore) newShowConfigCommand() *cli.Command {
+	return &cli.Command{
+		Name:        "show-config",
+		Usage:       "Show the UE configuration",
+		Description: "Show the SUPI, PLMN, configured NSSAI, default DNN, gNB search list and security algorithms of the UE",
+		Metadata: withSchema(CommandSchema{
+			Args: []models.ArgInfo{},
+		}),
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			nodeName, _ := GetNodeName(ctx)
+			inspector, ok := ueInspector(ctx)
+			if !ok {
+				unsupported(ctx, fmt.Sprintf("UE %s does not report its configuration", nodeName))
+				return nil
+			}
+
+			config, err := inspector.Config()
+			if err != nil {
+				Fail(ctx, models.ErrCodeOperationFailed, fmt.Sprintf("Failed to get the configuration of UE %s: %v", nodeName, err), nil)
+				return nil
+			}
+
+			table := fieldTable([][2]string{
+				{"supi", config.Supi},
+				{"plmn", config.Plmn},
+				{"configured-nssai", strings.Join(config.ConfiguredNssai, ", ")},
+				{"default-dnn", config.DefaultDnn},
+				{"gnb-search-list", strings.Join(config.GnbSearchList, ", ")},
+				{"integrity-algs", strings.Join(config.IntegrityAlgs, ", ")},
+				{"ciphering-algs", strings.Join(config.CipheringAlgs, ", ")},
+			})
+			SucceedWithTable(ctx, fmt.Sprintf("Configuration of UE %s", nodeName), nil, table)
+			return nil
+		},
+	}
+}
+
+// fieldTable lists the named fields of a node, one row per field in the
+// given order
+func fieldTable(fields [][2]string) *models.Table {
+	table := &models.Table{Columns: []string{"FIELD", "VALUE"}}
+	for _, field := range fields {
+		table.Rows = append(table.Rows, []string{field[0], field[1]})
+	}
+	return table
+}
+
+// securityText summarizes a NAS security context
+func securityText(sec models.SecurityContext) string {
+	if !sec.Active {
+		return "inactive"
+	}
+	return fmt.Sprintf("active, ngKSI %d, %s/%s", sec.Ngksi, sec.CipheringAlg, sec.IntegrityAlg)
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/TutuanHo03/remote-control/models"
+)
+
+// inspectedUe is a UE reporting a fixed state and configuration
+type inspectedUe struct{ fakeNode }
+
+func (inspectedUe) Status() (models.UeStatus, error) {
+	return models.UeStatus{RmState: "RM-REGISTERED", CmState: "CM-IDLE"}, nil
+}
+
+func (inspectedUe) Sessions() ([]models.PduSession, error) { return nil, nil }
+
+func (inspectedUe) Config() (models.UeConfig, error) {
+	return models.UeConfig{Supi: "imsi-001010000000001", Plmn: "00101"}, nil
+}
+
+func TestUeInspectionTables(t *testing.T) {
+	store := NewCommandStore(newFakeEmulator(), nil, nil)
+
+	for _, test := range []struct {
+		command string
+		row     []string
+	}{
+		{"status", []string{"rm-state", "RM-REGISTERED"}},
+		{"show-config", []string{"plmn", "00101"}},
+	} {
+		rspCh := make(chan models.CommandResult, 1)
+		ctx := context.WithValue(context.Background(), "rsp", rspCh)
+		ctx = context.WithValue(ctx, NodeKey, inspectedUe{})
+
+		cmd := store.newUeCommand()
+		if err := cmd.Run(ctx, []string{cmd.Name, test.command}); err != nil {
+			t.Fatalf("%s: %v", test.command, err)
+		}
+
+		result := <-rspCh
+		if result.Table == nil || !slices.Equal(result.Table.Columns, []string{"FIELD", "VALUE"}) {
+			t.Fatalf("%s: got table %+v, want FIELD and VALUE columns", test.command, result.Table)
+		}
+		if !slices.ContainsFunc(result.Table.Rows, func(row []string) bool { return slices.Equal(row, test.row) }) {
+			t.Errorf("%s: got rows %v, want %v", test.command, result.Table.Rows, test.row)
+		}
+	}
+}
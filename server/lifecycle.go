@@ -0,0 +1,164 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// DefaultShutdownTimeout is the default wait for running commands and jobs
+// on Shutdown
+const DefaultShutdownTimeout = 30 * time.Second
+
+// OnStart registers a hook run once the listener is bound, before requests
+// are served; an error aborts Start
+func (s *Server) OnStart(hook func() error) {
+	s.hooksMu.Lock()
+	defer s.hooksMu.Unlock()
+	s.startHooks = append(s.startHooks, hook)
+}
+
+// OnShutdown registers a hook run by Shutdown once the running commands and
+// jobs are drained, before the server resources are released, e.g. for the
+// emulator to release its UEs; ctx ends at the shutdown deadline
+func (s *Server) OnShutdown(hook func(ctx context.Context) error) {
+	s.hooksMu.Lock()
+	defer s.hooksMu.Unlock()
+	s.shutdownHooks = append(s.shutdownHooks, hook)
+}
+
+// Start serves the API until Shutdown; after a graceful shutdown it returns
+// nil once Shutdown completed
+func (s *Server) Start() error {
+	listener, err := net.Listen("tcp", s.httpServer.Addr)
+	if err != nil {
+		return err
+	}
+
+	scheme := "http"
+	if s.config.TLS != nil {
+		tlsConfig, err := s.config.TLS.config()
+		if err != nil {
+			listener.Close()
+			return err
+		}
+		s.httpServer.TLSConfig = tlsConfig
+		scheme = "https"
+	}
+
+	s.hooksMu.Lock()
+	hooks := append([]func() error{}, s.startHooks...)
+	s.hooksMu.Unlock()
+	for _, hook := range hooks {
+		if err := hook(); err != nil {
+			listener.Close()
+			return fmt.Errorf("start hook failed: %w", err)
+		}
+	}
+
+	log.Printf("Listening on %s://%s", scheme, s.httpServer.Addr)
+	if s.config.TLS != nil {
+		// The certificates are already loaded in the TLS configuration
+		err = s.httpServer.ServeTLS(listener, "", "")
+	} else {
+		err = s.httpServer.Serve(listener)
+	}
+	if !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	<-s.stopped
+	return nil
+}
+
+// Run starts the server and shuts it down gracefully when ctx is done or the
+// process receives SIGINT or SIGTERM
+func (s *Server) Run(ctx context.Context) error {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- s.Start()
+	}()
+
+	select {
+	case err := <-errCh:
+		s.Shutdown()
+		return err
+	case <-ctx.Done():
+		// A second signal terminates the process right away
+		stop()
+		log.Println("Shutdown requested")
+		err := s.Shutdown()
+		if startErr := <-errCh; startErr != nil {
+			return startErr
+		}
+		return err
+	}
+}
+
+// Shutdown stops the server gracefully: it stops accepting requests, waits
+// up to ShutdownTimeout for the running commands and jobs, runs the shutdown
+// hooks and releases the resources. Commands still running at the deadline
+// are canceled.
+func (s *Server) Shutdown() error {
+	s.shutdownOnce.Do(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), s.config.ShutdownTimeout)
+		defer cancel()
+		s.shutdownErr = s.shutdown(ctx)
+		close(s.stopped)
+	})
+	<-s.stopped
+	return s.shutdownErr
+}
+
+// shutdown drains the requests and jobs, then releases the resources
+func (s *Server) shutdown(ctx context.Context) error {
+	log.Println("Shutting down, waiting for running commands and jobs...")
+	var errs []error
+
+	// Event streams only end when the broker closes them
+	s.events.Close()
+
+	if err := s.httpServer.Shutdown(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("requests still running at the shutdown deadline: %w", err))
+		s.httpServer.Close()
+	}
+	if err := s.jobs.Shutdown(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("jobs still running at the shutdown deadline: %w", err))
+	}
+
+	s.hooksMu.Lock()
+	hooks := append([]func(context.Context) error{}, s.shutdownHooks...)
+	s.hooksMu.Unlock()
+	for _, hook := range hooks {
+		if err := hook(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("shutdown hook failed: %w", err))
+		}
+	}
+
+	log.Println("Cleaning up resources...")
+	s.ctxHandler.Close()
+	s.sessions.Close()
+	if s.audit != nil {
+		if err := s.audit.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close audit log: %w", err))
+		}
+	}
+
+	err := errors.Join(errs...)
+	if err != nil {
+		log.Printf("Shutdown completed with errors: %v", err)
+	} else {
+		log.Println("Shutdown completed")
+	}
+	return err
+}
@@ -0,0 +1,242 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/TutuanHo03/remote-control/mock"
+	"github.com/TutuanHo03/remote-control/models"
+	"github.com/TutuanHo03/remote-control/server/handlers"
+
+	"github.com/urfave/cli/v3"
+)
+
+// blockingType is a singleton node type whose wait command blocks until it
+// is released or canceled
+type blockingType struct {
+	started  chan struct{}
+	release  chan struct{}
+	mu       sync.Mutex
+	finished int
+}
+
+func newBlockingType() *blockingType {
+	return &blockingType{started: make(chan struct{}, 8), release: make(chan struct{})}
+}
+
+func (b *blockingType) spec() handlers.NodeTypeSpec {
+	return handlers.NodeTypeSpec{
+		Name:      "blocker",
+		Singleton: true,
+		Commands: func() *cli.Command {
+			return &cli.Command{
+				Name: "blocker",
+				Commands: []*cli.Command{
+					{
+						Name: "wait",
+						Action: func(ctx context.Context, cmd *cli.Command) error {
+							b.started <- struct{}{}
+							select {
+							case <-b.release:
+								b.mu.Lock()
+								b.finished++
+								b.mu.Unlock()
+								handlers.Succeed(ctx, "released", nil)
+							case <-ctx.Done():
+								handlers.Fail(ctx, models.ErrCodeCanceled, "canceled", nil)
+							}
+							return nil
+						},
+					},
+				},
+			}
+		},
+	}
+}
+
+// Finished returns how many wait commands were released
+func (b *blockingType) Finished() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.finished
+}
+
+// startTestServer starts a server on a free local port with a blocking node
+// type, and returns it with its URL and the result of Start
+func startTestServer(t *testing.T, shutdownTimeout time.Duration) (*Server, *blockingType, string, <-chan error) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+
+	emu := mock.NewEmulator(mock.DefaultConfig())
+	srv := NewServer(ServerConfig{Host: "127.0.0.1", Port: strconv.Itoa(port), ShutdownTimeout: shutdownTimeout}, emu, emu, emu)
+	blocker := newBlockingType()
+	if err := srv.RegisterNodeType(blocker.spec()); err != nil {
+		t.Fatal(err)
+	}
+
+	ready := make(chan struct{})
+	srv.OnStart(func() error {
+		close(ready)
+		return nil
+	})
+	startErr := make(chan error, 1)
+	go func() {
+		startErr <- srv.Start()
+	}()
+	select {
+	case <-ready:
+	case err := <-startErr:
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { srv.Shutdown() })
+
+	return srv, blocker, "http://127.0.0.1:" + strconv.Itoa(port), startErr
+}
+
+// runBlocked starts a wait command and a wait job and returns once both run;
+// the channel yields the HTTP status of the command, 0 when the connection
+// was closed
+func runBlocked(t *testing.T, url string, blocker *blockingType) (string, <-chan int) {
+	t.Helper()
+
+	req := models.CommandRequest{NodeType: "blocker", NodeName: "blocker", CommandPath: "wait"}
+	body, err := json.Marshal(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	status := make(chan int, 1)
+	go func() {
+		resp, err := http.Post(url+"/api/exec", "application/json", bytes.NewReader(body))
+		if err != nil {
+			status <- 0
+			return
+		}
+		resp.Body.Close()
+		status <- resp.StatusCode
+	}()
+
+	var job models.JobInfo
+	if code := postJSON(t, url+"/api/jobs", req, &job); code != http.StatusAccepted {
+		t.Fatalf("got status %d submitting the job", code)
+	}
+
+	for range 2 {
+		select {
+		case <-blocker.started:
+		case <-time.After(5 * time.Second):
+			t.Fatal("wait commands did not start")
+		}
+	}
+	return job.ID, status
+}
+
+func TestShutdownDrains(t *testing.T) {
+	srv, blocker, url, startErr := startTestServer(t, 10*time.Second)
+
+	// Hooks run in order, after the drain, and their errors are all returned
+	errFirst, errThird := errors.New("first hook"), errors.New("third hook")
+	var order []int
+	var finishedAtHooks int
+	srv.OnShutdown(func(context.Context) error {
+		order = append(order, 1)
+		finishedAtHooks = blocker.Finished()
+		return errFirst
+	})
+	srv.OnShutdown(func(context.Context) error {
+		order = append(order, 2)
+		return nil
+	})
+	srv.OnShutdown(func(ctx context.Context) error {
+		order = append(order, 3)
+		if _, ok := ctx.Deadline(); !ok {
+			t.Error("shutdown hook context has no deadline")
+		}
+		return errThird
+	})
+
+	jobID, status := runBlocked(t, url, blocker)
+
+	shutdownErr := make(chan error, 1)
+	go func() {
+		shutdownErr <- srv.Shutdown()
+	}()
+	select {
+	case err := <-shutdownErr:
+		t.Fatalf("Shutdown returned %v before the commands finished", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+	close(blocker.release)
+
+	var err error
+	select {
+	case err = <-shutdownErr:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Shutdown did not return after the commands finished")
+	}
+	if !errors.Is(err, errFirst) || !errors.Is(err, errThird) {
+		t.Errorf("got error %v, want both hook errors", err)
+	}
+	if strings.Contains(err.Error(), "deadline") {
+		t.Errorf("got error %v, want no deadline error", err)
+	}
+	if len(order) != 3 || order[0] != 1 || order[1] != 2 || order[2] != 3 {
+		t.Errorf("hooks ran in order %v, want [1 2 3]", order)
+	}
+	if finishedAtHooks != 2 {
+		t.Errorf("%d command(s) finished when the hooks ran, want 2", finishedAtHooks)
+	}
+
+	if code := <-status; code != http.StatusOK {
+		t.Errorf("got status %d for the running command, want %d", code, http.StatusOK)
+	}
+	if job, err := srv.jobs.Get(jobID); err != nil || job.State != models.JobSucceeded {
+		t.Errorf("got job %+v (%v), want it %s", job, err, models.JobSucceeded)
+	}
+	if err := <-startErr; err != nil {
+		t.Errorf("Start returned %v after a graceful shutdown", err)
+	}
+}
+
+func TestShutdownDeadline(t *testing.T) {
+	srv, blocker, url, startErr := startTestServer(t, 200*time.Millisecond)
+	defer close(blocker.release)
+
+	jobID, status := runBlocked(t, url, blocker)
+
+	start := time.Now()
+	err := srv.Shutdown()
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Shutdown took %s with a 200ms timeout", elapsed)
+	}
+	if err == nil || !strings.Contains(err.Error(), "requests still running") || !strings.Contains(err.Error(), "jobs still running") {
+		t.Errorf("got error %v, want the requests and jobs running at the deadline", err)
+	}
+
+	if code := <-status; code == http.StatusOK {
+		t.Error("command still running at the deadline succeeded")
+	}
+	if job, err := srv.jobs.Get(jobID); err != nil || job.State != models.JobCanceled {
+		t.Errorf("got job %+v (%v), want it %s", job, err, models.JobCanceled)
+	}
+	if blocker.Finished() != 0 {
+		t.Errorf("%d command(s) released, want none", blocker.Finished())
+	}
+	if err := <-startErr; err != nil {
+		t.Errorf("Start returned %v after shutdown", err)
+	}
+}
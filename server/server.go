@@ -1,8 +1,12 @@
 package server
 
 import (
+	"context"
 	"fmt"
-	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/TutuanHo03/remote-control/server/handlers"
 
@@ -10,8 +14,78 @@ import (
 )
 
 type ServerConfig struct {
-	Port string
-	Host string
+	Port           string
+	Host           string
+	SessionTimeout time.Duration // Idle time after which operator sessions expire
+	NodeSyncPeriod time.Duration // Interval between node context syncs with the emulator
+	CommandTimeout time.Duration // Default deadline of a command execution
+	JobWorkers     int           // Number of asynchronous jobs executed concurrently
+
+	// ShutdownTimeout bounds the wait for running commands and jobs on
+	// Shutdown, DefaultShutdownTimeout when 0
+	ShutdownTimeout time.Duration
+
+	// CommandTimeouts overrides the deadline of single commands,
+	// keyed by "<node-type> <command-path>", e.g. "ue register"
+	CommandTimeouts map[string]time.Duration
+
+	// TLS serves the API over HTTPS, nil serves plain HTTP
+	TLS *TLSConfig
+
+	// Auth enables authentication and role checks, nil leaves the API open
+	Auth *AuthConfig
+
+	// Audit records every command and navigation call, nil disables the audit log
+	Audit *AuditConfig
+
+	// AllowedOrigins lists the origins allowed by CORS, "*" allows any.
+	// When empty any origin is allowed without Auth and none with it.
+	AllowedOrigins []string
+}
+
+// AuthConfig - Authentication of the API; callers send a static API token or
+// a JWT as "Authorization: Bearer <token>"
+type AuthConfig struct {
+	Tokens        handlers.StaticTokens      // Long-lived API tokens and their caller
+	JWT           *handlers.JWTAuthenticator // JWT verification, disabled when nil
+	Authenticator handlers.Authenticator     // Custom authentication, tried first
+	Policy        *handlers.AccessPolicy     // Roles required by commands, handlers.DefaultAccessPolicy() when nil
+}
+
+// authenticator combines the configured authentication methods
+func (a *AuthConfig) authenticator() handlers.Authenticator {
+	var chain handlers.Authenticators
+	if a.Authenticator != nil {
+		chain = append(chain, a.Authenticator)
+	}
+	if len(a.Tokens) > 0 {
+		chain = append(chain, a.Tokens)
+	}
+	if a.JWT != nil {
+		chain = append(chain, a.JWT)
+	}
+	return chain
+}
+
+// AuditConfig - Destination of the audit log, a JSON-lines file rotated by
+// size or a custom sink
+type AuditConfig struct {
+	File     string             // Audit file, rotated to File.1, File.2, ...; audit.log when empty
+	MaxSize  int64              // Size in bytes at which the file is rotated, 10 MiB when 0
+	MaxFiles int                // Rotated files kept, 5 when 0
+	Sink     handlers.AuditSink // Custom sink, used instead of File
+}
+
+// sink returns the configured audit sink
+func (a *AuditConfig) sink() handlers.AuditSink {
+	if a.Sink != nil {
+		return a.Sink
+	}
+	file := a.File
+	if file == "" {
+		file = "audit.log"
+	}
+	return handlers.NewFileAuditSink(file, a.MaxSize, a.MaxFiles)
 }
 
 type Server struct {
@@ -19,37 +93,99 @@ type Server struct {
 	config     ServerConfig
 	cmdHandler *handlers.CommandStore
 	ctxHandler *handlers.ContextHandler
+	sessions   *handlers.SessionManager
+	jobs       *handlers.JobManager
+	events     *handlers.EventBroker
+	audit      *handlers.AuditLog
+	httpServer *http.Server
+
+	hooksMu       sync.Mutex
+	startHooks    []func() error
+	shutdownHooks []func(ctx context.Context) error
+	shutdownOnce  sync.Once
+	shutdownErr   error
+	stopped       chan struct{} // Closed once Shutdown completed
 }
 
-func NewServer(config ServerConfig, eApi handlers.EmulatorApi, uApi handlers.UeApi, gApi handlers.GnbApi) *Server {
+func NewServer(config ServerConfig, eApi handlers.EmulatorApi, ueProvider handlers.UeProvider, gnbProvider handlers.GnbProvider) *Server {
 	if config.Port == "" {
 		config.Port = "4000"
 	}
 	if config.Host == "" {
 		config.Host = "0.0.0.0"
 	}
+	if config.SessionTimeout == 0 {
+		config.SessionTimeout = handlers.DefaultSessionTimeout
+	}
+	if config.NodeSyncPeriod == 0 {
+		config.NodeSyncPeriod = 5 * time.Second
+	}
+	if config.ShutdownTimeout == 0 {
+		config.ShutdownTimeout = DefaultShutdownTimeout
+	}
 
 	r := gin.Default()
-	cmdHandler := handlers.NewCommandStore(eApi, uApi, gApi)
-	ctxHandler := handlers.NewContextHandler(cmdHandler)
+	cmdHandler := handlers.NewCommandStore(eApi, ueProvider, gnbProvider)
+	if config.Auth != nil {
+		policy := config.Auth.Policy
+		if policy == nil {
+			policy = handlers.DefaultAccessPolicy()
+		}
+		cmdHandler.SetAccessPolicy(policy)
+	}
+	var audit *handlers.AuditLog
+	if config.Audit != nil {
+		audit = handlers.NewAuditLog(config.Audit.sink())
+		cmdHandler.SetAuditLog(audit)
+	}
+	if config.CommandTimeout > 0 {
+		cmdHandler.SetDefaultTimeout(config.CommandTimeout)
+	}
+	for key, timeout := range config.CommandTimeouts {
+		nodeType, commandPath, _ := strings.Cut(key, " ")
+		cmdHandler.SetCommandTimeout(nodeType, commandPath, timeout)
+	}
+	sessions := handlers.NewSessionManager(config.SessionTimeout)
+	events := handlers.NewEventBroker(eApi)
+	ctxHandler := handlers.NewContextHandler(cmdHandler, sessions, events)
+	jobs := handlers.NewJobManager(cmdHandler, sessions, config.JobWorkers)
 
 	server := &Server{
 		router:     r,
 		config:     config,
 		cmdHandler: cmdHandler,
 		ctxHandler: ctxHandler,
+		sessions:   sessions,
+		jobs:       jobs,
+		events:     events,
+		audit:      audit,
+		httpServer: &http.Server{
+			Addr:    fmt.Sprintf("%s:%s", config.Host, config.Port),
+			Handler: r,
+		},
+		stopped: make(chan struct{}),
 	}
 
 	server.setupRoutes()
+	ctxHandler.StartNodeSync(config.NodeSyncPeriod)
 	return server
 }
 
 func (s *Server) setupRoutes() {
 	// CORS middleware
+	origins := s.config.AllowedOrigins
+	if len(origins) == 0 && s.config.Auth == nil {
+		origins = []string{"*"}
+	}
 	s.router.Use(func(c *gin.Context) {
-		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		if origin := allowedOrigin(origins, c.GetHeader("Origin")); origin != "" {
+			c.Writer.Header().Set("Access-Control-Allow-Origin", origin)
+			c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+			c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-API-Key")
+			if origin != "*" {
+				c.Writer.Header().Add("Vary", "Origin")
+			}
+		}
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
 			return
@@ -57,6 +193,15 @@ func (s *Server) setupRoutes() {
 		c.Next()
 	})
 
+	// Every API route below requires credentials when authentication is on
+	adminOnly := func(c *gin.Context) { c.Next() }
+	operatorOnly := adminOnly
+	if s.config.Auth != nil {
+		s.router.Use(handlers.AuthMiddleware(s.config.Auth.authenticator()))
+		adminOnly = handlers.RequireRole(handlers.RoleAdmin)
+		operatorOnly = handlers.RequireRole(handlers.RoleOperator)
+	}
+
 	// API routes
 	s.router.GET("/api/context", func(c *gin.Context) {
 		c.JSON(200, gin.H{
@@ -82,13 +227,42 @@ func (s *Server) setupRoutes() {
 
 	s.router.POST("/api/context/navigate", s.ctxHandler.NavigateContext)
 	s.router.POST("/api/exec", s.ctxHandler.ExecuteCommand)
+	s.router.POST("/api/bulk", s.ctxHandler.ExecuteBulk)
+
+	s.router.GET("/api/sessions", adminOnly, s.sessions.ListSessions)
+
+	s.router.POST("/api/jobs", s.jobs.SubmitJob)
+	s.router.GET("/api/jobs", s.jobs.ListJobs)
+	s.router.GET("/api/jobs/:id", s.jobs.GetJob)
+	s.router.DELETE("/api/jobs/:id", operatorOnly, s.jobs.CancelJob)
+
+	s.router.GET("/api/events", s.events.StreamEvents)
+
+	if s.audit != nil {
+		s.router.GET("/api/audit", adminOnly, s.audit.ListAudit)
+	}
 }
 
-func (s *Server) Start() error {
-	address := fmt.Sprintf("%s:%s", s.config.Host, s.config.Port)
-	return s.router.Run(address)
+// allowedOrigin returns the Access-Control-Allow-Origin value for a request
+// origin, empty when the origin is not allowed
+func allowedOrigin(allowed []string, origin string) string {
+	for _, o := range allowed {
+		if o == "*" {
+			return "*"
+		}
+		if origin != "" && strings.EqualFold(o, origin) {
+			return origin
+		}
+	}
+	return ""
 }
 
-func (s *Server) Shutdown() {
-	log.Println("Cleaning up resources...")
+// RegisterNodeType adds a node type, e.g. an AMF or a UPF, next to the
+// emulator, UE and gNB types; its context set is available right away
+func (s *Server) RegisterNodeType(spec handlers.NodeTypeSpec) error {
+	if err := s.cmdHandler.RegisterNodeType(spec); err != nil {
+		return err
+	}
+	s.ctxHandler.SyncNodes()
+	return nil
 }
@@ -0,0 +1,402 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/TutuanHo03/remote-control/mock"
+	"github.com/TutuanHo03/remote-control/models"
+	"github.com/TutuanHo03/remote-control/server/handlers"
+
+	"github.com/gin-gonic/gin"
+	"github.com/urfave/cli/v3"
+)
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	gin.DefaultWriter = io.Discard
+	os.Exit(m.Run())
+}
+
+// newTestServer serves the API of a server backed by the mock emulator
+func newTestServer(t *testing.T, config ServerConfig) (*Server, *httptest.Server) {
+	t.Helper()
+
+	emu := mock.NewEmulator(mock.DefaultConfig())
+	srv := NewServer(config, emu, emu, emu)
+	ts := httptest.NewServer(srv.router)
+	t.Cleanup(func() {
+		ts.Close()
+		srv.Shutdown()
+	})
+	return srv, ts
+}
+
+// postJSON posts a JSON body and decodes the JSON answer into out
+func postJSON(t *testing.T, url string, body, out any) int {
+	t.Helper()
+	return doJSON(t, http.MethodPost, url, "", body, out)
+}
+
+// doJSON sends a request with an optional JSON body and bearer token, and
+// decodes the JSON answer into out
+func doJSON(t *testing.T, method, url, token string, body, out any) int {
+	t.Helper()
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, url, reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Error(err)
+		return 0
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		t.Errorf("%s %s: %v", method, url, err)
+	}
+	return resp.StatusCode
+}
+
+// hammer runs fn from many goroutines at once
+func hammer(t *testing.T, goroutines int, fn func(worker int)) {
+	t.Helper()
+
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			<-start
+			fn(worker)
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+}
+
+func TestConcurrentNavigation(t *testing.T) {
+	_, ts := newTestServer(t, ServerConfig{})
+	url := ts.URL + "/api/context/navigate"
+	supis := mock.DefaultConfig().Ues
+
+	hammer(t, 20, func(worker int) {
+		var connected models.NavigationResponse
+		if status := postJSON(t, url, models.NavigationRequest{Command: "connect", Args: []string{ts.URL}}, &connected); status != http.StatusOK {
+			t.Errorf("connect: HTTP %d %s", status, connected.Error)
+			return
+		}
+
+		steps := []models.NavigationRequest{
+			{Command: "use", Args: []string{"ue"}},
+			{Command: "select", Args: []string{supis[worker%len(supis)]}},
+			{Command: "back"},
+			{Command: "use", Args: []string{"gnb"}},
+			{Command: "select", Args: []string{"gnb1"}},
+			{Command: "use", Args: []string{"emulator"}},
+			{Command: "back"},
+		}
+		for round := 0; round < 5; round++ {
+			for _, step := range steps {
+				step.SessionID = connected.SessionID
+				var resp models.NavigationResponse
+				if status := postJSON(t, url, step, &resp); status != http.StatusOK {
+					t.Errorf("%s %v: HTTP %d %s", step.Command, step.Args, status, resp.Error)
+				}
+			}
+		}
+
+		var resp models.NavigationResponse
+		postJSON(t, url, models.NavigationRequest{SessionID: connected.SessionID, Command: "disconnect"}, &resp)
+	})
+}
+
+func TestConcurrentExec(t *testing.T) {
+	_, ts := newTestServer(t, ServerConfig{})
+	url := ts.URL + "/api/exec"
+	supis := mock.DefaultConfig().Ues
+
+	hammer(t, 20, func(worker int) {
+		supi := supis[worker%len(supis)]
+		added := fmt.Sprintf("imsi-0010100000%05d", 100+worker)
+
+		requests := []models.CommandRequest{
+			{NodeType: "emulator", NodeName: "emulator", RawCommand: "list-ue"},
+			{NodeType: "emulator", NodeName: "emulator", RawCommand: "add-ue " + added},
+			{NodeType: "ue", NodeName: supi, RawCommand: "register"},
+			{NodeType: "ue", NodeName: supi, CommandPath: "session create", Flags: map[string]string{"dn": "ims", "type": "1"}},
+			{NodeType: "ue", NodeName: supi, RawCommand: "status"},
+			{NodeType: "gnb", NodeName: "gnb1", RawCommand: "list-ues"},
+			{NodeType: "gnb", NodeName: "gnb1", RawCommand: "release-session " + supi + " --id 1"},
+			{NodeType: "ue", NodeName: added, RawCommand: "show-config"},
+			{NodeType: "emulator", NodeName: "emulator", RawCommand: "remove-ue " + added},
+		}
+		for round := 0; round < 5; round++ {
+			for _, req := range requests {
+				var resp models.CommandResponse
+				if status := postJSON(t, url, req, &resp); status != http.StatusOK {
+					t.Errorf("%s %s %s%s: HTTP %d %s", req.NodeType, req.NodeName, req.CommandPath, req.RawCommand, status, resp.Error)
+				}
+			}
+		}
+	})
+}
+
+func TestJobAccess(t *testing.T) {
+	_, ts := newTestServer(t, ServerConfig{Auth: &AuthConfig{
+		Tokens: handlers.StaticTokens{
+			"alice": {Subject: "alice", Role: handlers.RoleOperator},
+			"bob":   {Subject: "bob", Role: handlers.RoleOperator},
+			"carol": {Subject: "carol", Role: handlers.RoleViewer},
+			"root":  {Subject: "root", Role: handlers.RoleAdmin},
+		},
+	}})
+
+	submit := func(token string) models.JobInfo {
+		var info models.JobInfo
+		req := models.CommandRequest{NodeType: "emulator", NodeName: "emulator", CommandPath: "list-ue"}
+		if status := doJSON(t, http.MethodPost, ts.URL+"/api/jobs", token, req, &info); status != http.StatusAccepted {
+			t.Fatalf("submit as %s: HTTP %d", token, status)
+		}
+		if info.User != token {
+			t.Errorf("job of %s records user %q", token, info.User)
+		}
+		return info
+	}
+	aliceJob := submit("alice")
+	carolJob := submit("carol")
+
+	list := func(token string) map[string]bool {
+		var infos []models.JobInfo
+		if status := doJSON(t, http.MethodGet, ts.URL+"/api/jobs", token, nil, &infos); status != http.StatusOK {
+			t.Fatalf("list as %s: HTTP %d", token, status)
+		}
+		ids := make(map[string]bool)
+		for _, info := range infos {
+			ids[info.ID] = true
+		}
+		return ids
+	}
+	if ids := list("alice"); !ids[aliceJob.ID] || ids[carolJob.ID] {
+		t.Errorf("alice lists %v, want only her job", ids)
+	}
+	if ids := list("bob"); len(ids) != 0 {
+		t.Errorf("bob lists %v, want no job", ids)
+	}
+	if ids := list("root"); !ids[aliceJob.ID] || !ids[carolJob.ID] {
+		t.Errorf("root lists %v, want every job", ids)
+	}
+
+	for _, tc := range []struct {
+		method, token, id string
+		status            int
+	}{
+		{http.MethodGet, "alice", aliceJob.ID, http.StatusOK},
+		{http.MethodGet, "bob", aliceJob.ID, http.StatusNotFound},
+		{http.MethodGet, "root", aliceJob.ID, http.StatusOK},
+		{http.MethodDelete, "bob", aliceJob.ID, http.StatusNotFound},
+		{http.MethodDelete, "carol", carolJob.ID, http.StatusForbidden},
+		{http.MethodDelete, "alice", aliceJob.ID, http.StatusOK},
+		{http.MethodDelete, "root", carolJob.ID, http.StatusOK},
+	} {
+		var out map[string]any
+		if status := doJSON(t, tc.method, ts.URL+"/api/jobs/"+tc.id, tc.token, nil, &out); status != tc.status {
+			t.Errorf("%s job as %s: HTTP %d, want %d", tc.method, tc.token, status, tc.status)
+		}
+	}
+}
+
+func TestNodeEvents(t *testing.T) {
+	srv, ts := newTestServer(t, ServerConfig{})
+	url := ts.URL + "/api/exec"
+
+	// Syncing the tree once per added UE would not end in time
+	var resp models.CommandResponse
+	req := models.CommandRequest{NodeType: "emulator", NodeName: "emulator", RawCommand: fmt.Sprintf("add-ue-range imsi-001010000100000 %d", handlers.MaxUeRange)}
+	if status := postJSON(t, url, req, &resp); status != http.StatusOK || !resp.Result.Succeeded() {
+		t.Fatalf("add-ue-range: HTTP %d %s %+v", status, resp.Error, resp.Result)
+	}
+
+	events, unsubscribe := srv.events.Subscribe()
+	defer unsubscribe()
+
+	// Let the sync of the range end, its events would crowd out the others
+	for quiet := false; !quiet; {
+		select {
+		case <-events:
+		case <-time.After(200 * time.Millisecond):
+			quiet = true
+		}
+	}
+
+	// Nodes are synced in the background, wait for each change to be seen
+	// so the removal is not folded into the sync of the addition
+	supi := "imsi-001010000000100"
+	counts := make(map[models.EventType]int)
+	for _, step := range []struct {
+		command string
+		event   models.EventType
+	}{
+		{"add-ue " + supi, models.EventNodeAdded},
+		{"remove-ue " + supi, models.EventNodeRemoved},
+	} {
+		req := models.CommandRequest{NodeType: "emulator", NodeName: "emulator", RawCommand: step.command}
+		if status := postJSON(t, url, req, &resp); status != http.StatusOK || !resp.Result.Succeeded() {
+			t.Fatalf("%s: HTTP %d %s", step.command, status, resp.Error)
+		}
+
+		timeout := time.After(5 * time.Second)
+		for counts[step.event] == 0 {
+			select {
+			case event := <-events:
+				if event.NodeName == supi {
+					counts[event.Type]++
+				}
+			case <-timeout:
+				t.Fatalf("%s: no %s event", step.command, step.event)
+			}
+		}
+	}
+
+	// Collect the events until the stream is quiet, duplicates would follow shortly
+	for quiet := false; !quiet; {
+		select {
+		case event := <-events:
+			if event.NodeName == supi {
+				counts[event.Type]++
+			}
+		case <-time.After(200 * time.Millisecond):
+			quiet = true
+		}
+	}
+	if counts[models.EventNodeAdded] != 1 || counts[models.EventNodeRemoved] != 1 {
+		t.Errorf("got %d node added and %d node removed events, want one each", counts[models.EventNodeAdded], counts[models.EventNodeRemoved])
+	}
+}
+
+func TestCreateSessionDefaultType(t *testing.T) {
+	_, ts := newTestServer(t, ServerConfig{})
+	url := ts.URL + "/api/exec"
+	supi := mock.DefaultConfig().Ues[0]
+
+	for _, command := range []string{"register", "create-session", "session create"} {
+		var resp models.CommandResponse
+		req := models.CommandRequest{NodeType: "ue", NodeName: supi, RawCommand: command}
+		if status := postJSON(t, url, req, &resp); status != http.StatusOK || !resp.Result.Succeeded() {
+			t.Errorf("%s: HTTP %d %s %+v", command, status, resp.Error, resp.Result)
+		}
+	}
+}
+
+func TestSessionAccess(t *testing.T) {
+	_, ts := newTestServer(t, ServerConfig{Auth: &AuthConfig{
+		Tokens: handlers.StaticTokens{
+			"alice": {Subject: "alice", Role: handlers.RoleOperator},
+			"bob":   {Subject: "bob", Role: handlers.RoleOperator},
+			"root":  {Subject: "root", Role: handlers.RoleAdmin},
+		},
+	}})
+	navigate := ts.URL + "/api/context/navigate"
+
+	var connected models.NavigationResponse
+	if status := doJSON(t, http.MethodPost, navigate, "alice", models.NavigationRequest{Command: "connect", Args: []string{ts.URL}}, &connected); status != http.StatusOK {
+		t.Fatalf("connect as alice: HTTP %d %s", status, connected.Error)
+	}
+
+	var sessions []models.SessionInfo
+	if status := doJSON(t, http.MethodGet, ts.URL+"/api/sessions", "root", nil, &sessions); status != http.StatusOK || len(sessions) != 1 || sessions[0].User != "alice" {
+		t.Errorf("sessions: HTTP %d %+v, want the session of alice", status, sessions)
+	}
+
+	for _, tc := range []struct {
+		token  string
+		status int
+	}{
+		{"bob", http.StatusForbidden},
+		{"alice", http.StatusOK},
+		{"root", http.StatusOK},
+	} {
+		var resp models.NavigationResponse
+		req := models.NavigationRequest{SessionID: connected.SessionID, Command: "use", Args: []string{"emulator"}}
+		if status := doJSON(t, http.MethodPost, navigate, tc.token, req, &resp); status != tc.status {
+			t.Errorf("navigate as %s: HTTP %d %s, want %d", tc.token, status, resp.Error, tc.status)
+		}
+
+		var out models.CommandResponse
+		exec := models.CommandRequest{SessionID: connected.SessionID, CommandPath: "list-ue"}
+		if status := doJSON(t, http.MethodPost, ts.URL+"/api/exec", tc.token, exec, &out); status != tc.status {
+			t.Errorf("exec as %s: HTTP %d %s, want %d", tc.token, status, out.Error, tc.status)
+		}
+
+		want := http.StatusAccepted
+		if tc.status == http.StatusForbidden {
+			want = http.StatusForbidden
+		}
+		var info map[string]any
+		if status := doJSON(t, http.MethodPost, ts.URL+"/api/jobs", tc.token, exec, &info); status != want {
+			t.Errorf("submit job as %s: HTTP %d, want %d", tc.token, status, want)
+		}
+	}
+}
+
+func TestCommandPanic(t *testing.T) {
+	srv, ts := newTestServer(t, ServerConfig{})
+	err := srv.RegisterNodeType(handlers.NodeTypeSpec{
+		Name:      "faulty",
+		Singleton: true,
+		Commands: func() *cli.Command {
+			return &cli.Command{
+				Name: "faulty",
+				Commands: []*cli.Command{
+					{
+						Name: "crash",
+						Action: func(ctx context.Context, cmd *cli.Command) error {
+							var nodes []string
+							_ = nodes[0]
+							return nil
+						},
+					},
+				},
+			}
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var resp models.CommandResponse
+	req := models.CommandRequest{NodeType: "faulty", NodeName: "faulty", CommandPath: "crash"}
+	if status := postJSON(t, ts.URL+"/api/exec", req, &resp); status != http.StatusInternalServerError {
+		t.Errorf("got HTTP %d %s, want %d", status, resp.Error, http.StatusInternalServerError)
+	}
+
+	// The server keeps serving after the panic
+	req = models.CommandRequest{NodeType: "emulator", NodeName: "emulator", RawCommand: "list-ue"}
+	if status := postJSON(t, ts.URL+"/api/exec", req, &resp); status != http.StatusOK {
+		t.Errorf("got HTTP %d %s after the panic, want %d", status, resp.Error, http.StatusOK)
+	}
+}
@@ -0,0 +1,199 @@
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"log"
+	"math/big"
+	"net"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/TutuanHo03/remote-control/client"
+	"github.com/TutuanHo03/remote-control/mock"
+)
+
+// testPKI - PEM files of a test CA and of the server and client certificates it issued
+type testPKI struct {
+	CAFile     string
+	ServerCert string
+	ServerKey  string
+	ClientCert string
+	ClientKey  string
+}
+
+// newTestPKI generates a CA, a server certificate for 127.0.0.1 and localhost
+// and a client certificate in a temporary directory
+func newTestPKI(t *testing.T) testPKI {
+	t.Helper()
+
+	dir := t.TempDir()
+	pki := testPKI{
+		CAFile:     filepath.Join(dir, "ca.pem"),
+		ServerCert: filepath.Join(dir, "server.pem"),
+		ServerKey:  filepath.Join(dir, "server-key.pem"),
+		ClientCert: filepath.Join(dir, "client.pem"),
+		ClientKey:  filepath.Join(dir, "client-key.pem"),
+	}
+
+	caKey := newTestKey(t)
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "remote-control test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writePEM(t, pki.CAFile, "CERTIFICATE", caDER)
+
+	issue := func(serial int64, template *x509.Certificate, certFile, keyFile string) {
+		key := newTestKey(t)
+		template.SerialNumber = big.NewInt(serial)
+		template.NotBefore = ca.NotBefore
+		template.NotAfter = ca.NotAfter
+		template.KeyUsage = x509.KeyUsageDigitalSignature
+
+		der, err := x509.CreateCertificate(rand.Reader, template, ca, &key.PublicKey, caKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keyDER, err := x509.MarshalECPrivateKey(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		writePEM(t, certFile, "CERTIFICATE", der)
+		writePEM(t, keyFile, "EC PRIVATE KEY", keyDER)
+	}
+	issue(2, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "localhost"},
+		DNSNames:    []string{"localhost"},
+		IPAddresses: []net.IP{net.IPv4(127, 0, 0, 1)},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, pki.ServerCert, pki.ServerKey)
+	issue(3, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "operator"},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, pki.ClientCert, pki.ClientKey)
+
+	return pki
+}
+
+// newTestKey generates a P-256 private key
+func newTestKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// writePEM writes one PEM block to a file
+func writePEM(t *testing.T, path, blockType string, der []byte) {
+	t.Helper()
+
+	data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// newTLSTestServer serves the API of a server backed by the mock emulator over
+// HTTPS, with the TLS configuration the server would listen with
+func newTLSTestServer(t *testing.T, config *TLSConfig) *httptest.Server {
+	t.Helper()
+
+	emu := mock.NewEmulator(mock.DefaultConfig())
+	srv := NewServer(ServerConfig{TLS: config}, emu, emu, emu)
+	tlsConfig, err := config.config()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ts := httptest.NewUnstartedServer(srv.router)
+	ts.TLS = tlsConfig
+	// Rejected handshakes are expected
+	ts.Config.ErrorLog = log.New(io.Discard, "", 0)
+	ts.StartTLS()
+	t.Cleanup(func() {
+		ts.Close()
+		srv.Shutdown()
+	})
+	return ts
+}
+
+// connect connects a client with TLS options to a server
+func connect(url string, opts client.TLSOptions) error {
+	c := client.NewScriptClient(io.Discard)
+	if err := c.SetTLS(opts); err != nil {
+		return err
+	}
+	return c.ConnectToServer(url)
+}
+
+func TestHTTPS(t *testing.T) {
+	pki, other := newTestPKI(t), newTestPKI(t)
+	ts := newTLSTestServer(t, &TLSConfig{CertFile: pki.ServerCert, KeyFile: pki.ServerKey})
+
+	for _, tc := range []struct {
+		name    string
+		opts    client.TLSOptions
+		success bool
+	}{
+		{"system CAs", client.TLSOptions{}, false},
+		{"CA bundle", client.TLSOptions{CAFile: pki.CAFile}, true},
+		{"insecure", client.TLSOptions{InsecureSkipVerify: true}, true},
+		{"other CA bundle", client.TLSOptions{CAFile: other.CAFile}, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := connect(ts.URL, tc.opts)
+			if tc.success && err != nil {
+				t.Errorf("connect failed: %v", err)
+			}
+			if !tc.success && err == nil {
+				t.Error("connect succeeded, want a certificate error")
+			}
+		})
+	}
+}
+
+func TestMutualTLS(t *testing.T) {
+	pki := newTestPKI(t)
+	ts := newTLSTestServer(t, &TLSConfig{
+		CertFile:     pki.ServerCert,
+		KeyFile:      pki.ServerKey,
+		ClientCAFile: pki.CAFile,
+	})
+
+	if err := connect(ts.URL, client.TLSOptions{CAFile: pki.CAFile}); err == nil {
+		t.Error("client without a certificate connected")
+	}
+
+	other := newTestPKI(t)
+	if err := connect(ts.URL, client.TLSOptions{CAFile: pki.CAFile, CertFile: other.ClientCert, KeyFile: other.ClientKey}); err == nil {
+		t.Error("client with a certificate of another CA connected")
+	}
+
+	if err := connect(ts.URL, client.TLSOptions{CAFile: pki.CAFile, CertFile: pki.ClientCert, KeyFile: pki.ClientKey}); err != nil {
+		t.Errorf("client with a certificate failed to connect: %v", err)
+	}
+}